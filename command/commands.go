@@ -3,6 +3,7 @@ package command
 import (
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"text/template"
 )
@@ -10,12 +11,21 @@ import (
 type MakeStruct map[string]Operation
 
 type Operation struct {
-	Script     []string         `yaml:"script,omitempty"`
-	Doc        string           `yaml:"doc,omitempty"`
-	Image      *DockerOperation `yaml:"image,omitempty"`
-	On_Failure []string         `yaml:"on_failure,omitempty"`
-	Stage      string           `yaml:"stage,omitempty"`
-	Color      string           `yaml:"color,omitempty"`
+	Script       []string          `yaml:"script,omitempty"`
+	Doc          string            `yaml:"doc,omitempty"`
+	Image        *DockerOperation  `yaml:"image,omitempty"`
+	On_Failure   []string          `yaml:"on_failure,omitempty"`
+	On_Interrupt []string          `yaml:"on_interrupt,omitempty"`
+	Stage        string            `yaml:"stage,omitempty"`
+	Color        string            `yaml:"color,omitempty"`
+	Needs        []string          `yaml:"needs,omitempty"`
+	Sources      []string          `yaml:"sources,omitempty"`
+	Generates    []string          `yaml:"generates,omitempty"`
+	Timeout      string            `yaml:"timeout,omitempty"`
+	Retry        *RetryOperation   `yaml:"retry,omitempty"`
+	Env          map[string]string `yaml:"env,omitempty"`
+	Dir          string            `yaml:"dir,omitempty"`
+	Params       []ParamOperation  `yaml:"params,omitempty"`
 }
 
 type DockerOperation struct {
@@ -27,26 +37,63 @@ type DockerOperation struct {
 	Entrypoint string
 }
 
+type RetryOperation struct {
+	Attempts int    `yaml:"attempts,omitempty"`
+	Delay    string `yaml:"delay,omitempty"`
+	// constant (default) or exponential
+	Backoff string `yaml:"backoff,omitempty"`
+}
+
+// ParamOperation is a parameter of a command given as --name value at gomake run
+type ParamOperation struct {
+	Name string `yaml:"name"`
+	// string (default), int, float or bool
+	Type        string   `yaml:"type,omitempty"`
+	Default     string   `yaml:"default,omitempty"`
+	Required    bool     `yaml:"required,omitempty"`
+	Description string   `yaml:"description,omitempty"`
+	Enum        []string `yaml:"enum,omitempty"`
+}
+
 type CommandListType string
 
 const (
-	CommandListTypeScript   CommandListType = "script"
-	CommandListTypeOnFailer CommandListType = "onFailer"
+	CommandListTypeScript      CommandListType = "script"
+	CommandListTypeOnFailer    CommandListType = "onFailer"
+	CommandListTypeOnInterrupt CommandListType = "onInterrupt"
 )
 
 type Command interface {
 	Name() string
-	Execute(cmd string, makefile MakeStruct, listType CommandListType) ([]string, error)
+	// chain is the list of commands which includes lead to cmd (starting with the executed command)
+	Execute(cmd string, makefile MakeStruct, listType CommandListType, chain []string) ([]string, error)
 	GetFuncMap() template.FuncMap
 }
 
+const DefaultMaxIncludeDepth = 32
+
 type CommandHandler struct {
 	appName string
 	handler map[string]Command
+	// maximum length of an include chain
+	maxIncludeDepth int
+	// shared by all copies of the handler
+	hooks *handlerHooks
+}
+
+// ArgsRenderFunc renders the whole makefile again with args available as .Args
+type ArgsRenderFunc func(args map[string]any) (MakeStruct, error)
+
+// MaskFunc replaces secrets at the text
+type MaskFunc func(text string) string
+
+type handlerHooks struct {
+	render ArgsRenderFunc
+	mask   MaskFunc
 }
 
-func NewCommandHandler(appName string) CommandHandler {
-	res := CommandHandler{appName: appName, handler: make(map[string]Command)}
+func NewCommandHandler(appName string, maxIncludeDepth int) CommandHandler {
+	res := CommandHandler{appName: appName, handler: make(map[string]Command), maxIncludeDepth: maxIncludeDepth, hooks: &handlerHooks{}}
 	res.registerStandardHandler()
 	return res
 }
@@ -72,7 +119,7 @@ func (c *CommandHandler) ExecuteVariablesCommands(variabels map[string]any) (map
 			for k1, v := range c.handler {
 
 				if k1 == command[0] {
-					tmp, err := v.Execute(command[1], nil, "")
+					tmp, err := v.Execute(command[1], nil, "", nil)
 					if err != nil {
 						return nil, err
 					}
@@ -89,6 +136,16 @@ func (c *CommandHandler) ExecuteVariablesCommands(variabels map[string]any) (map
 
 }
 
+// SetArgsRenderer sets the func used to render commands included with args
+func (c *CommandHandler) SetArgsRenderer(render ArgsRenderFunc) {
+	c.hooks.render = render
+}
+
+// SetMasker sets the func used to hide secrets at the echo of SliceCommands
+func (c *CommandHandler) SetMasker(mask MaskFunc) {
+	c.hooks.mask = mask
+}
+
 func (c *CommandHandler) RegisterHandler(cmd Command) error {
 	if _, ok := c.handler[cmd.Name()]; ok {
 		return fmt.Errorf("%s allready exist as Handler", cmd.Name())
@@ -98,11 +155,12 @@ func (c *CommandHandler) RegisterHandler(cmd Command) error {
 }
 
 func (c *CommandHandler) GetExecutedCommandMakeScript(cmd string, data MakeStruct) (MakeStruct, error) {
+	chain := []string{cmd}
 
 	commands := make([]string, 0)
 
 	for _, commandLine := range data[cmd].Script {
-		t, err := c.commandExecuter(commandLine, data, CommandListTypeScript)
+		t, err := c.commandExecuter(commandLine, data, CommandListTypeScript, chain)
 		if err != nil {
 			return nil, err
 		}
@@ -111,23 +169,74 @@ func (c *CommandHandler) GetExecutedCommandMakeScript(cmd string, data MakeStruc
 
 	onFailer := make([]string, 0)
 	for _, commandLine := range data[cmd].On_Failure {
-		t, err := c.commandExecuter(commandLine, data, CommandListTypeOnFailer)
+		t, err := c.commandExecuter(commandLine, data, CommandListTypeOnFailer, chain)
 		if err != nil {
 			return nil, err
 		}
 		onFailer = append(onFailer, t...)
 	}
+
+	onInterrupt := make([]string, 0)
+	for _, commandLine := range data[cmd].On_Interrupt {
+		t, err := c.commandExecuter(commandLine, data, CommandListTypeOnInterrupt, chain)
+		if err != nil {
+			return nil, err
+		}
+		onInterrupt = append(onInterrupt, t...)
+	}
 	res := make(MakeStruct)
 	res[cmd] = Operation{
-		Script:     commands,
-		On_Failure: onFailer,
-		Image:      data[cmd].Image,
-		Color:      data[cmd].Color,
-		Stage:      data[cmd].Stage,
+		Script:       commands,
+		On_Failure:   onFailer,
+		On_Interrupt: onInterrupt,
+		Image:        data[cmd].Image,
+		Color:        data[cmd].Color,
+		Stage:        data[cmd].Stage,
+		Needs:        data[cmd].Needs,
+		Sources:      data[cmd].Sources,
+		Generates:    data[cmd].Generates,
+		Timeout:      data[cmd].Timeout,
+		Retry:        data[cmd].Retry,
+		Env:          data[cmd].Env,
+		Dir:          data[cmd].Dir,
+		Params:       data[cmd].Params,
 	}
 	return res, nil
 }
 
+// GetIncludes returns the sorted names of all commands the operation includes directly
+func (c *CommandHandler) GetIncludes(operation Operation) []string {
+	prefix := fmt.Sprintf("__%s_%s=", c.appName, (&IncludeCommand{}).Name())
+	found := make(map[string]bool)
+	for _, list := range [][]string{operation.Script, operation.On_Failure, operation.On_Interrupt} {
+		for _, line := range list {
+			if strings.HasPrefix(line, prefix) {
+				name, _, _ := parseIncludeArgs(strings.TrimPrefix(line, prefix))
+				found[name] = true
+			}
+		}
+	}
+	res := make([]string, 0, len(found))
+	for name := range found {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// RenameInclude renames the command included at line (if it is an include)
+func (c *CommandHandler) RenameInclude(line string, rename func(name string) string) string {
+	prefix := fmt.Sprintf("__%s_%s=", c.appName, (&IncludeCommand{}).Name())
+	if !strings.HasPrefix(line, prefix) {
+		return line
+	}
+	name, args, found := strings.Cut(strings.TrimPrefix(line, prefix), includeArgsSeparator)
+	if !found {
+		return prefix + rename(name)
+	}
+	return prefix + rename(name) + includeArgsSeparator + args
+}
+
 func (c *CommandHandler) GetFuncMap() template.FuncMap {
 	res := make(template.FuncMap)
 	for _, v := range c.handler {
@@ -139,7 +248,7 @@ func (c *CommandHandler) GetFuncMap() template.FuncMap {
 	return res
 }
 
-func (c *CommandHandler) commandExecuter(cmd string, data MakeStruct, listType CommandListType) ([]string, error) {
+func (c *CommandHandler) commandExecuter(cmd string, data MakeStruct, listType CommandListType, chain []string) ([]string, error) {
 	prefix := fmt.Sprintf("__%s_", c.appName)
 	if strings.HasPrefix(cmd, prefix) {
 		command := strings.SplitN(strings.TrimLeft(cmd, prefix), "=", 2)
@@ -149,7 +258,7 @@ func (c *CommandHandler) commandExecuter(cmd string, data MakeStruct, listType C
 		for k, v := range c.handler {
 
 			if k == command[0] {
-				return v.Execute(command[1], data, listType)
+				return v.Execute(command[1], data, listType, chain)
 			}
 		}
 	}
@@ -158,8 +267,15 @@ func (c *CommandHandler) commandExecuter(cmd string, data MakeStruct, listType C
 
 func (c *CommandHandler) SliceCommands(cmdList []string) string {
 	res := ""
-	for _, c := range cmdList {
-		res += fmt.Sprintf("echo \"\\$ %s\";%s; ", c, c)
+	for _, cmd := range cmdList {
+		if c.hooks.mask != nil {
+			if masked := c.hooks.mask(cmd); masked != cmd {
+				// single quoted, so the shell does not expand the mask
+				res += fmt.Sprintf("echo '$ %s';%s; ", strings.ReplaceAll(masked, "'", `'\''`), cmd)
+				continue
+			}
+		}
+		res += fmt.Sprintf("echo \"\\$ %s\";%s; ", cmd, cmd)
 	}
 	return res
 }
@@ -1,8 +1,13 @@
 package command
 
 import (
+	"encoding/base64"
 	"fmt"
+	"strings"
 	"text/template"
+
+	nearfinder "github.com/fasibio/gomake/nearFinder"
+	"gopkg.in/yaml.v2"
 )
 
 type IncludeCommand struct {
@@ -13,18 +18,71 @@ func (i *IncludeCommand) Name() string {
 	return "include"
 }
 
+const includeArgsSeparator = ";args="
+
+// GetFuncMap returns the include template func, optional args (like dict "env" "prod") are available as .Args at the included command
 func (i *IncludeCommand) GetFuncMap() template.FuncMap {
 	return template.FuncMap{
-		i.Name(): func(name string) string {
-			return fmt.Sprintf("- __%s_%s=%s", i.handler.appName, i.Name(), name)
+		i.Name(): func(name string, args ...map[string]any) (string, error) {
+			if len(args) == 0 {
+				return fmt.Sprintf("- __%s_%s=%s", i.handler.appName, i.Name(), name), nil
+			}
+			b, err := yaml.Marshal(args[0])
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("- __%s_%s=%s%s%s", i.handler.appName, i.Name(), name, includeArgsSeparator, base64.RawURLEncoding.EncodeToString(b)), nil
 		},
 	}
 }
 
-func (i *IncludeCommand) Execute(cmd string, makefile MakeStruct, listType CommandListType) ([]string, error) {
+// parseIncludeArgs splits the value of an include into command name and args (nil if there are none)
+func parseIncludeArgs(cmd string) (string, map[string]any, error) {
+	name, encodedArgs, found := strings.Cut(cmd, includeArgsSeparator)
+	if !found {
+		return cmd, nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(encodedArgs)
+	if err != nil {
+		return name, nil, fmt.Errorf("invalid args of include %s: %s", name, err)
+	}
+	args := make(map[string]any)
+	if err := yaml.Unmarshal(b, &args); err != nil {
+		return name, nil, fmt.Errorf("invalid args of include %s: %s", name, err)
+	}
+	return name, args, nil
+}
+
+func (i *IncludeCommand) Execute(cmd string, makefile MakeStruct, listType CommandListType, chain []string) ([]string, error) {
+	cmd, args, err := parseIncludeArgs(cmd)
+	if err != nil {
+		return []string{}, err
+	}
+	if args != nil {
+		if i.handler.hooks.render == nil {
+			return []string{}, fmt.Errorf("include %s with args is not supported here", cmd)
+		}
+		makefile, err = i.handler.hooks.render(args)
+		if err != nil {
+			return []string{}, fmt.Errorf("render %s with args: %s", cmd, err)
+		}
+	}
 	if _, ok := makefile[cmd]; !ok {
-		return []string{}, fmt.Errorf("%s not exist, so can not include", cmd)
+		suggestion := ""
+		if closest := nearfinder.ClosestMatchOrTypo(cmd, nearfinder.GetKeysOfMap(makefile), 2); closest != "" {
+			suggestion = fmt.Sprintf(", did you mean %s", closest)
+		}
+		return []string{}, fmt.Errorf("%s not exist, so can not include%s", cmd, suggestion)
 	}
+	for k, c := range chain {
+		if c == cmd {
+			return []string{}, fmt.Errorf("include cycle: %s -> %s", strings.Join(chain[k:], " -> "), cmd)
+		}
+	}
+	if i.handler.maxIncludeDepth > 0 && len(chain) > i.handler.maxIncludeDepth {
+		return []string{}, fmt.Errorf("maximum include depth of %d exceeded: %s -> %s", i.handler.maxIncludeDepth, strings.Join(chain, " -> "), cmd)
+	}
+	chain = append(chain[:len(chain):len(chain)], cmd)
 	var list []string
 	switch listType {
 	case "script":
@@ -33,10 +91,13 @@ func (i *IncludeCommand) Execute(cmd string, makefile MakeStruct, listType Comma
 	case "onFailer":
 		list = makefile[cmd].On_Failure
 		break
+	case "onInterrupt":
+		list = makefile[cmd].On_Interrupt
+		break
 	}
 	res := make([]string, 0)
 	for _, c := range list {
-		t, err := i.handler.commandExecuter(c, makefile, listType)
+		t, err := i.handler.commandExecuter(c, makefile, listType, chain)
 		if err != nil {
 			return []string{}, err
 		}
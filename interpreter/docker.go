@@ -1,26 +1,75 @@
 package interpreter
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/fasibio/gomake/command"
 )
 
-func getDockerCmd(cmd string, docker *command.DockerOperation) string {
+// docker allows only [a-zA-Z0-9][a-zA-Z0-9_.-]* as container name
+var containerNameReplaceRegex = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// getContainerName returns an unique name for the container of the operation
+func getContainerName(operation string) string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("gomake-%s-%s", containerNameReplaceRegex.ReplaceAllString(operation, "-"), hex.EncodeToString(b))
+}
+
+// removeContainer kills and removes the container, killing docker run only stops the client but not the container
+func removeContainer(name string) error {
+	return exec.Command("docker", "rm", "-f", name).Run()
+}
+
+// getDockerCmd wraps cmd into docker run of a container with name, only an interactive cmd gets a tty.
+// env and dir are set inside the container, a relative dir is relative to the workdir of the image.
+// The script runs behind an init process, so it gets the signals forwarded by docker run.
+func getDockerCmd(cmd, name string, docker *command.DockerOperation, env map[string]string, dir string, interactive bool) string {
 	executer := "/bin/sh"
 	if docker.Executer != "" {
 		executer = docker.Executer
 	}
 
 	var sb strings.Builder
-	sb.WriteString("docker run --rm -it ")
+	sb.WriteString(fmt.Sprintf("docker run --rm --init --name %s ", shellQuote(name)))
+	if interactive {
+		sb.WriteString("-it ")
+	}
 	for _, v := range docker.Volumes {
 		sb.WriteString(fmt.Sprintf("-v %s ", v))
 	}
+	for _, k := range getSortedKeys(env) {
+		sb.WriteString(fmt.Sprintf("-e %s ", shellQuote(fmt.Sprintf("%s=%s", k, env[k]))))
+	}
+	if filepath.IsAbs(dir) {
+		sb.WriteString(fmt.Sprintf("-w %s ", shellQuote(dir)))
+	} else if dir != "" {
+		// docker only accepts absolute workdirs, relative ones are relative to the workdir of the image
+		cmd = fmt.Sprintf("cd %s || exit 1; %s", shellQuote(dir), cmd)
+	}
 	sb.WriteString(fmt.Sprintf("%s ", docker.Name))
-	sb.WriteString(fmt.Sprintf("%s -c '%s'", executer, cmd))
+	sb.WriteString(fmt.Sprintf("%s -c %s", executer, shellQuote(cmd)))
 
 	cmd = sb.String()
 	return cmd
 }
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func getSortedKeys(m map[string]string) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
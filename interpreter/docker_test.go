@@ -0,0 +1,105 @@
+package interpreter
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fasibio/gomake/command"
+)
+
+// stubDocker is a docker which runs the script given after -c on the host, docker rm is logged to $DOCKER_LOG
+const stubDocker = `#!/bin/sh
+if [ "$1" = rm ]; then echo "$@" >> "$DOCKER_LOG"; exit 0; fi
+while [ "$1" != "-c" ]; do shift; done
+shift
+[ $# -eq 1 ] || { echo "expected the script as one argument, got $#"; exit 2; }
+exec /bin/sh -c "$1"
+`
+
+func TestGetDockerCmdMaskedSecret(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "docker"), []byte(stubDocker), 0755); err != nil {
+		t.Fatal(err)
+	}
+	handler := command.NewCommandHandler("gomake", 0)
+	secrets := newSecretRegistry()
+	secrets.add("abc123")
+	handler.SetMasker(secrets.mask)
+
+	script := handler.SliceCommands([]string{`echo "using abc123" it\'s`})
+	cmd := exec.Command("/bin/sh", "-c", getDockerCmd(script, "gomake-test", &command.DockerOperation{Name: "alpine"}, nil, "", false))
+	cmd.Env = append(os.Environ(), "PATH="+bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s: %s", err, out)
+	}
+	want := "$ echo \"using ***\" it\\'s\nusing abc123 it's\n"
+	if string(out) != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	if strings.Contains(strings.SplitN(string(out), "\n", 2)[0], "abc123") {
+		t.Errorf("secret at echo: %q", out)
+	}
+}
+
+func TestGetDockerCmdRelativeDir(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "docker"), []byte(stubDocker), 0755); err != nil {
+		t.Fatal(err)
+	}
+	workdir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(workdir, "build"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	dockerCmd := getDockerCmd("basename $(pwd)", "gomake-test", &command.DockerOperation{Name: "alpine"}, nil, "build", false)
+	if strings.Contains(dockerCmd, "-w") {
+		t.Errorf("relative dir passed as -w: %s", dockerCmd)
+	}
+	cmd := exec.Command("/bin/sh", "-c", dockerCmd)
+	cmd.Dir = workdir
+	cmd.Env = append(os.Environ(), "PATH="+bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s: %s", err, out)
+	}
+	if string(out) != "build\n" {
+		t.Errorf("got %q, want build", out)
+	}
+
+	if dockerCmd := getDockerCmd("pwd", "gomake-test", &command.DockerOperation{Name: "alpine"}, nil, "/src", false); !strings.Contains(dockerCmd, "-w '/src' ") {
+		t.Errorf("absolute dir not passed as -w: %s", dockerCmd)
+	}
+}
+
+func TestImageTimeoutRemovesContainer(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "docker"), []byte(stubDocker), 0755); err != nil {
+		t.Fatal(err)
+	}
+	log := filepath.Join(t.TempDir(), "docker.log")
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("DOCKER_LOG", log)
+
+	r := NewInterpreter("gomake", "", "sh", false, command.NewCommandHandler("gomake", 0), nil)
+	operator := StageOperationWrapper{Name: "slow", Command: command.Operation{
+		Script:  []string{"sleep 5"},
+		Image:   &command.DockerOperation{Name: "alpine"},
+		Timeout: "100ms",
+	}}
+	err := r.execScript(context.Background(), operator, false)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("got %v, want timed out", err)
+	}
+	b, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), "rm -f gomake-slow-") {
+		t.Errorf("container not removed: %q", b)
+	}
+}
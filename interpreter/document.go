@@ -0,0 +1,147 @@
+package interpreter
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// --- at column 0 (optional followed by a comment) starts a new yaml document, inside of scripts it is always indented
+	documentSeparatorRegex = regexp.MustCompile(`^---(?:\s.*)?$`)
+	yamlErrorRegex         = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+)
+
+// yamlDocument is a document of a gomake file, offset is the line before its first line
+type yamlDocument struct {
+	content string
+	offset  int
+}
+
+// gomakeDocuments are the documents of a gomake file: vars, commands and config
+type gomakeDocuments struct {
+	vars     yamlDocument
+	commands yamlDocument
+	// nil if the file has no config document
+	config *yamlDocument
+}
+
+// splitDocuments splits the gomake file at the lines of the document separators.
+// A single document are the commands, otherwise the vars come first and the optional config last.
+func splitDocuments(source, file string) (gomakeDocuments, error) {
+	lines := strings.Split(file, "\n")
+	docs := []yamlDocument{}
+	start := 0
+	for i, line := range lines {
+		if !documentSeparatorRegex.MatchString(line) {
+			continue
+		}
+		doc := yamlDocument{content: strings.Join(lines[start:i], "\n"), offset: start}
+		// a separator at the start of the file starts the first document
+		if len(docs) > 0 || !isEmptyDocument(doc.content) {
+			docs = append(docs, doc)
+		}
+		start = i + 1
+		if len(docs) == 3 {
+			return gomakeDocuments{}, &TemplateError{File: source, Line: i + 1, Message: "unexpected document separator (---), only the vars, commands and config documents are allowed"}
+		}
+	}
+	docs = append(docs, yamlDocument{content: strings.Join(lines[start:], "\n"), offset: start})
+
+	switch len(docs) {
+	case 1:
+		return gomakeDocuments{commands: docs[0]}, nil
+	case 2:
+		return gomakeDocuments{vars: docs[0], commands: docs[1]}, nil
+	default:
+		return gomakeDocuments{vars: docs[0], commands: docs[1], config: &docs[2]}, nil
+	}
+}
+
+func isEmptyDocument(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+// checkRenderedDocument fails if the rendered commands contain a document separator (like a var with ---)
+func checkRenderedDocument(content []byte, m sourceMap) error {
+	for i, line := range strings.Split(string(content), "\n") {
+		if documentSeparatorRegex.MatchString(line) {
+			file, line := m.locate(i + 1)
+			return &TemplateError{File: file, Line: line, Message: "the rendered commands contain a document separator (---) at column 0"}
+		}
+	}
+	return nil
+}
+
+// toYamlError adds file and line to errors of yaml (like yaml: line 3: ...), locate maps the line of the yaml to the file
+func toYamlError(err error, locate func(line int) (string, int)) error {
+	msg := err.Error()
+	if typeErr, ok := err.(*yaml.TypeError); ok && len(typeErr.Errors) > 0 {
+		msg = typeErr.Errors[0]
+	}
+	line, message, ok := parseYamlError(msg)
+	if !ok {
+		return err
+	}
+	file, line := locate(line)
+	return &TemplateError{File: file, Line: line, Message: message}
+}
+
+// parseYamlError returns line and message of an error message of yaml (like yaml: line 3: ...)
+func parseYamlError(msg string) (int, string, bool) {
+	m := yamlErrorRegex.FindStringSubmatch(msg)
+	if m == nil {
+		return 0, "", false
+	}
+	line, _ := strconv.Atoi(m[1])
+	return line, m[2], true
+}
+
+// ConfigDocument is the optional third document of a gomake file
+type ConfigDocument struct {
+	Config Config `yaml:"config"`
+}
+
+// Config are settings of gomake, flags given at the command line overwrite them.
+// The values are parsed like the flags.
+type Config struct {
+	Shell           string `yaml:"shell,omitempty"`
+	Timeout         string `yaml:"timeout,omitempty"`
+	GracePeriod     string `yaml:"grace_period,omitempty"`
+	MaxIncludeDepth string `yaml:"max_include_depth,omitempty"`
+	CacheTTL        string `yaml:"cache_ttl,omitempty"`
+	Strict          string `yaml:"strict,omitempty"`
+	ExportVars      string `yaml:"export_vars,omitempty"`
+	MaxParallel     string `yaml:"max_parallel,omitempty"`
+	FailFast        string `yaml:"fail_fast,omitempty"`
+}
+
+var configTypeReplacer = strings.NewReplacer("in type interpreter.ConfigDocument", "at the config document", "in type interpreter.Config", "at config")
+
+// GetConfig returns the config document of the gomake file (empty if there is none)
+func GetConfig(source string, file []byte) (Config, error) {
+	docs, err := splitDocuments(source, string(file))
+	if templateErr, ok := err.(*TemplateError); ok {
+		templateErr.setText(string(file))
+	}
+	if err != nil || docs.config == nil {
+		return Config{}, err
+	}
+	var config ConfigDocument
+	if err := yaml.UnmarshalStrict([]byte(docs.config.content), &config); err != nil {
+		err = toYamlError(err, templateSource{file: source, offset: docs.config.offset}.locate)
+		if templateErr, ok := err.(*TemplateError); ok {
+			templateErr.Message = configTypeReplacer.Replace(templateErr.Message)
+			templateErr.setText(string(file))
+		}
+		return Config{}, err
+	}
+	return config.Config, nil
+}
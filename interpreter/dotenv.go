@@ -0,0 +1,132 @@
+package interpreter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	dotenvLineRegex = regexp.MustCompile(`^(?:export\s+)?([A-Za-z_][A-Za-z0-9_.]*)\s*=\s*(.*)$`)
+	envKeyRegex     = regexp.MustCompile(`[^A-Za-z0-9_]`)
+)
+
+// loadDotenvFiles reads all files in order, later files overwrite earlier ones.
+// Not existing files are skipped if optional is set.
+func loadDotenvFiles(files []string, optional bool) (map[string]string, error) {
+	res := make(map[string]string)
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			if optional && os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		values, err := parseDotenv(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", f, err)
+		}
+		for k, v := range values {
+			res[k] = v
+		}
+	}
+	return res, nil
+}
+
+// parseDotenv parses lines like KEY=value, export KEY="value\n" or KEY='value', # starts a comment
+func parseDotenv(content []byte) (map[string]string, error) {
+	res := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m := dotenvLineRegex.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("line %d: expected KEY=value", lineNumber)
+		}
+		value, err := parseDotenvValue(m[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", lineNumber, err)
+		}
+		res[m[1]] = value
+	}
+	return res, scanner.Err()
+}
+
+func parseDotenvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		end := strings.LastIndex(value, `"`)
+		if end == 0 {
+			return "", fmt.Errorf("missing closing \"")
+		}
+		r := strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+		return r.Replace(value[1:end]), nil
+	case strings.HasPrefix(value, "'"):
+		end := strings.LastIndex(value, "'")
+		if end == 0 {
+			return "", fmt.Errorf("missing closing '")
+		}
+		return value[1:end], nil
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value), nil
+}
+
+// getExportedVars returns all vars as environment variables like GOMAKE_VAR_DOCKER_TAG, lists are json encoded
+func (r *Interpreter) getExportedVars(vars map[string]any) map[string]string {
+	res := make(map[string]string)
+	var add func(prefix string, vars map[string]any)
+	add = func(prefix string, vars map[string]any) {
+		for k, v := range vars {
+			key := prefix + strings.ToUpper(envKeyRegex.ReplaceAllString(k, "_"))
+			if m, ok := toVarsMap(v); ok {
+				add(key+"_", m)
+				continue
+			}
+			switch v.(type) {
+			case []any:
+				b, err := json.Marshal(normalizeVars(v))
+				if err == nil {
+					res[key] = string(b)
+				}
+			case nil:
+				res[key] = ""
+			default:
+				res[key] = fmt.Sprint(v)
+			}
+		}
+	}
+	add(fmt.Sprintf("%s_VAR_", r.App), vars)
+	return res
+}
+
+// normalizeVars converts all nested yaml maps to map[string]any (needed for json)
+func normalizeVars(v any) any {
+	if m, ok := toVarsMap(v); ok {
+		res := make(map[string]any)
+		for k, v := range m {
+			res[k] = normalizeVars(v)
+		}
+		return res
+	}
+	if l, ok := v.([]any); ok {
+		res := make([]any, 0, len(l))
+		for _, v := range l {
+			res = append(res, normalizeVars(v))
+		}
+		return res
+	}
+	return v
+}
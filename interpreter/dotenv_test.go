@@ -0,0 +1,75 @@
+package interpreter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fasibio/gomake/command"
+)
+
+func TestParseDotenv(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr string
+	}{
+		{name: "plain", content: "A=1\nB = two words \n", want: map[string]string{"A": "1", "B": "two words"}},
+		{name: "export prefix", content: "export A=1\nexport  B=2\n", want: map[string]string{"A": "1", "B": "2"}},
+		{name: "comments and empty lines", content: "# comment\n\n  # indented\nA=1 # trailing\nB=a#b\n", want: map[string]string{"A": "1", "B": "a#b"}},
+		{name: "double quotes", content: `A="a b # no comment"` + "\n" + `B="line\nnext\ttab \"quoted\" \\"`, want: map[string]string{"A": "a b # no comment", "B": "line\nnext\ttab \"quoted\" \\"}},
+		{name: "single quotes without escapes", content: `A='a\nb $HOME'`, want: map[string]string{"A": `a\nb $HOME`}},
+		{name: "empty value", content: "A=\nB=\"\"\n", want: map[string]string{"A": "", "B": ""}},
+		{name: "value with =", content: "A=b=c\n", want: map[string]string{"A": "b=c"}},
+		{name: "later wins", content: "A=1\nA=2\n", want: map[string]string{"A": "2"}},
+		{name: "windows line endings", content: "A=1\r\nB=2\r\n", want: map[string]string{"A": "1", "B": "2"}},
+		{name: "missing =", content: "A=1\nINVALID\n", wantErr: "line 2: expected KEY=value"},
+		{name: "invalid key", content: "1A=1\n", wantErr: "line 1: expected KEY=value"},
+		{name: "unclosed double quote", content: `A="abc`, wantErr: `line 1: missing closing "`},
+		{name: "unclosed single quote", content: `A='abc`, wantErr: "line 1: missing closing '"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDotenv([]byte(tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got %v, %v, want error %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDotenvOfIncludedFileWins(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"outer.env":  "GOMAKE_TEST_SHARED=outer\nGOMAKE_TEST_OUTER=outer\n",
+		"inner.env":  "GOMAKE_TEST_SHARED=inner\nGOMAKE_TEST_INNER=inner\n",
+		"helper.yml": "dotenv: [" + filepath.Join(dir, "inner.env") + "]\n---\nhelper:\n  script:\n    - echo helper\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file := "dotenv: [" + filepath.Join(dir, "outer.env") + "]\n---\n{{includeFile \"" + filepath.Join(dir, "helper.yml") + "\"}}\nshow:\n  script:\n    - echo show\n"
+	r := NewInterpreter("gomake", "", "sh", false, command.NewCommandHandler("gomake", 0), []byte(file))
+	r.MakeFile = "gomake.yml"
+	if _, _, err := r.GetExecuteTemplate(file, make(map[string]any)); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"GOMAKE_TEST_SHARED": "inner", "GOMAKE_TEST_OUTER": "outer", "GOMAKE_TEST_INNER": "inner"}
+	if !reflect.DeepEqual(r.scriptEnv, want) {
+		t.Errorf("got %v, want %v", r.scriptEnv, want)
+	}
+}
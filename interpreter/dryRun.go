@@ -16,10 +16,10 @@ type DryRunOutput struct {
 
 type SDryRunOutput map[string]any
 
-func (i Interpreter) printDryRun(command []StageOperationWrapper, variables map[string]map[string]any) error {
+func (i Interpreter) printDryRun(command []StageOperationWrapper, variables VarsDocument) error {
 
 	outPutData := make(SDryRunOutput)
-	outPutData["vars"] = variables["vars"]
+	outPutData["vars"] = variables.Vars
 	for _, c := range command {
 		outPutData[c.Name] = c.Command
 	}
@@ -27,6 +27,17 @@ func (i Interpreter) printDryRun(command []StageOperationWrapper, variables map[
 	if err != nil {
 		return err
 	}
+	if !i.secrets.isEmpty() {
+		// mask the decoded values, so also quoted or escaped secrets are found
+		var data any
+		if err := yaml.Unmarshal(out, &data); err != nil {
+			return err
+		}
+		out, err = yaml.Marshal(i.secrets.maskValue(data))
+		if err != nil {
+			return err
+		}
+	}
 	reader := bytes.NewReader(out)
 	r, err := highlight.Highlight(reader)
 	fmt.Print(r)
@@ -0,0 +1,127 @@
+package interpreter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fasibio/gomake/command"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	StateDir          = ".gomake"
+	fingerprintSubDir = "fingerprints"
+)
+
+var fingerprintNameReplacer = strings.NewReplacer("/", "_", "\\", "_", ":", "_")
+
+func getFingerprintPath(name string) string {
+	return filepath.Join(StateDir, fingerprintSubDir, fingerprintNameReplacer.Replace(name))
+}
+
+// getFingerprint hashes the rendered operation and the content of all files matching its sources
+func getFingerprint(operation command.Operation) (string, error) {
+	h := sha256.New()
+	rendered, err := yaml.Marshal(operation)
+	if err != nil {
+		return "", err
+	}
+	h.Write(rendered)
+
+	files := make([]string, 0)
+	for _, pattern := range operation.Sources {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return "", err
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+	for i, f := range files {
+		if i > 0 && files[i-1] == f {
+			continue
+		}
+		info, err := os.Stat(f)
+		if err != nil {
+			return "", err
+		}
+		if info.IsDir() {
+			continue
+		}
+		fileHash, err := hashFile(f)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s %s\n", filepath.ToSlash(f), fileHash)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// generatesExist checks that every generates pattern matches at least one file
+func generatesExist(generates []string) (bool, error) {
+	for _, pattern := range generates {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return false, err
+		}
+		if len(matches) == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// isUpToDate returns the current fingerprint of the operation and if it matches the stored one.
+// Operations without sources are never up to date.
+func (r *Interpreter) isUpToDate(operator StageOperationWrapper) (string, bool, error) {
+	if len(operator.Command.Sources) == 0 {
+		return "", false, nil
+	}
+	fingerprint, err := getFingerprint(operator.Command)
+	if err != nil {
+		return "", false, err
+	}
+	if r.Force {
+		return fingerprint, false, nil
+	}
+	stored, err := os.ReadFile(getFingerprintPath(operator.Name))
+	if err != nil {
+		return fingerprint, false, nil
+	}
+	if strings.TrimSpace(string(stored)) != fingerprint {
+		return fingerprint, false, nil
+	}
+	exist, err := generatesExist(operator.Command.Generates)
+	return fingerprint, exist, err
+}
+
+func saveFingerprint(name, fingerprint string) error {
+	path := getFingerprintPath(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(fingerprint), 0644)
+}
+
+func removeFingerprint(name string) {
+	os.Remove(getFingerprintPath(name))
+}
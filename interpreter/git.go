@@ -0,0 +1,196 @@
+package interpreter
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	gitPrefix     = "git+"
+	gitCacheDir   = "git"
+	gitRefQuery   = "?ref="
+	gitDefaultRef = "HEAD"
+)
+
+// gitInclude is a file of a git repository like git+https://host/org/helpers.git//ci/go.yml?ref=v1.2
+type gitInclude struct {
+	repo string
+	// file (or glob) inside of the repository
+	path string
+	// branch, tag or commit
+	ref string
+}
+
+func isGitPath(p string) bool {
+	return strings.HasPrefix(p, gitPrefix)
+}
+
+func parseGitPath(p string) (gitInclude, error) {
+	s := strings.TrimPrefix(p, gitPrefix)
+	res := gitInclude{ref: gitDefaultRef}
+	if i := strings.LastIndex(s, "?"); i >= 0 {
+		query, err := url.ParseQuery(s[i+1:])
+		if err != nil {
+			return res, err
+		}
+		if ref := query.Get("ref"); ref != "" {
+			res.ref = ref
+		}
+		if strings.HasPrefix(res.ref, "-") {
+			return res, fmt.Errorf("invalid ref %s, it must not start with -", res.ref)
+		}
+		s = s[:i]
+	}
+	scheme := strings.Index(s, "://")
+	if scheme < 0 {
+		return res, fmt.Errorf("missing scheme of the repository (like git+https://)")
+	}
+	sep := strings.Index(s[scheme+3:], "//")
+	if sep < 0 {
+		return res, fmt.Errorf("missing // between repository and file (like git+https://host/org/helpers.git//ci/go.yml)")
+	}
+	res.repo = s[:scheme+3+sep]
+	res.path = s[scheme+3+sep+2:]
+	if res.path == "" {
+		return res, fmt.Errorf("missing file after //")
+	}
+	return res, nil
+}
+
+func (g gitInclude) lockKey() string {
+	return g.repo + gitRefQuery + g.ref
+}
+
+func (g gitInclude) source(file string) string {
+	return fmt.Sprintf("%s%s//%s%s%s", gitPrefix, g.repo, file, gitRefQuery, g.ref)
+}
+
+// getGitContents returns the files of a git include at the commit pinned at gomake.lock.
+// Unknown refs are resolved to a commit and added to gomake.lock.
+func (r *Interpreter) getGitContents(p, sum string) ([]FileContent, error) {
+	include, err := parseGitPath(p)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := r.getLockFile()
+	if err != nil {
+		return nil, err
+	}
+	dir := getCachePath(gitCacheDir, include.repo)
+	commit, locked := lock.Refs[include.lockKey()]
+	if !locked {
+		if commit, err = r.resolveGitRef(dir, include.repo, include.ref); err != nil {
+			return nil, err
+		}
+		lock.Refs[include.lockKey()] = commit
+		if err := r.saveLockFile(); err != nil {
+			return nil, err
+		}
+	} else if _, err := runGit(dir, "cat-file", "-e", commit+"^{commit}"); err != nil {
+		if r.Offline {
+			return nil, fmt.Errorf("commit %s of %s is not cached, it can not be fetched in offline mode", commit, include.repo)
+		}
+		if err := syncGitMirror(dir, include.repo); err != nil {
+			return nil, err
+		}
+		if _, err := runGit(dir, "cat-file", "-e", commit+"^{commit}"); err != nil {
+			return nil, fmt.Errorf("commit %s of %s pinned at %s does not exist (run %s deps update)", commit, include.repo, r.getLockFilePath(), r.App)
+		}
+	}
+
+	files, err := getGitFiles(dir, commit, include.path)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s does not exist at %s (commit %s): %w", include.path, include.repo, commit, os.ErrNotExist)
+	}
+	res := make([]FileContent, 0, len(files))
+	for _, file := range files {
+		content, err := runGit(dir, "show", commit+":"+file)
+		if err != nil {
+			return nil, err
+		}
+		if sum != "" {
+			if err := verifySum(include.source(file), content, sum); err != nil {
+				return nil, err
+			}
+		}
+		res = append(res, FileContent{Source: include.source(file), Content: content})
+	}
+	return res, nil
+}
+
+// resolveGitRef returns the commit of ref, the cached clone is updated first (except offline)
+func (r *Interpreter) resolveGitRef(dir, repo, ref string) (string, error) {
+	if r.Offline {
+		if _, err := os.Stat(dir); err != nil {
+			return "", fmt.Errorf("%s is not cached, it can not be cloned in offline mode", repo)
+		}
+	} else if err := syncGitMirror(dir, repo); err != nil {
+		return "", err
+	}
+	commit, err := runGit(dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("ref %s does not exist at %s", ref, repo)
+	}
+	return strings.TrimSpace(commit), nil
+}
+
+// syncGitMirror clones repo to dir or fetches all refs if it is already cloned
+func syncGitMirror(dir, repo string) error {
+	if _, err := os.Stat(dir); err == nil {
+		_, err := runGit(dir, "fetch", "--quiet", "--prune", "--tags", "origin")
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	// -- so a repo starting with - is no option of git
+	if _, err := runGit("", "clone", "--quiet", "--mirror", "--", repo, dir); err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	return nil
+}
+
+// getGitFiles returns the sorted files at commit matching pattern
+func getGitFiles(dir, commit, pattern string) ([]string, error) {
+	out, err := runGit(dir, "ls-tree", "-r", "--name-only", commit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, 0)
+	for _, file := range strings.Split(strings.TrimSpace(out), "\n") {
+		if ok, _ := path.Match(pattern, file); ok {
+			res = append(res, file)
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	// fail instead of waiting for credentials
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// the first line is the reason, the following ones are hints
+		if msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n"); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], err)
+	}
+	return string(out), nil
+}
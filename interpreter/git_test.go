@@ -0,0 +1,164 @@
+package interpreter
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fasibio/gomake/command"
+)
+
+// newGitTestRepo creates a bare repository with ci/go.yml (content) tagged as v1 and returns its url and the work tree
+func newGitTestRepo(t *testing.T, content string) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	for _, k := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(k, "gomake")
+	}
+	for _, k := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(k, "gomake@example.com")
+	}
+	dir := t.TempDir()
+	work := filepath.Join(dir, "work")
+	if err := os.MkdirAll(filepath.Join(work, "ci"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mustGit(t, work, "init", "--quiet")
+	commitGitTestFile(t, work, content)
+	mustGit(t, work, "tag", "v1")
+	bare := filepath.Join(dir, "helpers.git")
+	mustGit(t, dir, "clone", "--quiet", "--bare", work, bare)
+	return "file://" + bare, work
+}
+
+func commitGitTestFile(t *testing.T, work, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(work, "ci", "go.yml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mustGit(t, work, "add", "-A")
+	mustGit(t, work, "commit", "--quiet", "-m", content)
+}
+
+func mustGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := runGit(dir, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(out)
+}
+
+// chdirTemp changes to a temp dir, the cache of git includes is relative to the working directory
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	old, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(old) })
+	return dir
+}
+
+func newGitTestInterpreter(dir string) Interpreter {
+	r := NewInterpreter("gomake", "", "sh", false, command.NewCommandHandler("gomake", 0), nil)
+	r.MakeFile = filepath.Join(dir, "gomake.yml")
+	return r
+}
+
+func getGitTestContent(t *testing.T, r *Interpreter, p string) string {
+	t.Helper()
+	files, err := r.getGitContents(p, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("got %d files, want 1", len(files))
+	}
+	return files[0].Content
+}
+
+func TestGitIncludePinsCommit(t *testing.T) {
+	repo, work := newGitTestRepo(t, "first")
+	first := mustGit(t, work, "rev-parse", "HEAD")
+	dir := chdirTemp(t)
+	p := "git+" + repo + "//ci/go.yml?ref=v1"
+
+	r := newGitTestInterpreter(dir)
+	if content := getGitTestContent(t, &r, p); content != "first" {
+		t.Errorf("got %q, want first", content)
+	}
+	lock, err := os.ReadFile(filepath.Join(dir, LockFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(lock), repo+"?ref=v1: "+first) {
+		t.Errorf("commit %s not pinned at %s:\n%s", first, LockFileName, lock)
+	}
+
+	// move the tag, the pinned commit is used also with a fresh cache
+	commitGitTestFile(t, work, "second")
+	second := mustGit(t, work, "rev-parse", "HEAD")
+	mustGit(t, work, "tag", "-f", "v1")
+	mustGit(t, work, "push", "--quiet", "--force", strings.TrimPrefix(repo, "file://"), "refs/tags/v1")
+	if err := os.RemoveAll(StateDir); err != nil {
+		t.Fatal(err)
+	}
+	r = newGitTestInterpreter(dir)
+	if content := getGitTestContent(t, &r, p); content != "first" {
+		t.Errorf("got %q after moving the tag, want the pinned first", content)
+	}
+
+	changes, err := r.UpdateDeps(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || !strings.Contains(changes[0], first+" -> "+second) {
+		t.Errorf("got changes %v, want %s -> %s", changes, first, second)
+	}
+	r = newGitTestInterpreter(dir)
+	if content := getGitTestContent(t, &r, p); content != "second" {
+		t.Errorf("got %q after deps update, want second", content)
+	}
+}
+
+func TestParseGitPathErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"missing scheme", "git+host/org/helpers.git//ci/go.yml", "missing scheme"},
+		{"missing separator", "git+https://host/org/helpers.git/ci/go.yml", "missing //"},
+		{"empty file", "git+https://host/org/helpers.git//?ref=v1", "missing file"},
+		{"ref like an option", "git+https://host/org/helpers.git//ci/go.yml?ref=--output=x", "invalid ref"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseGitPath(tt.path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncGitMirrorRepoLikeOption(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := "--upload-pack=false"
+	err := syncGitMirror(filepath.Join(t.TempDir(), "mirror"), repo)
+	// git clone names the repository which does not exist, it would name the dir if repo is an option
+	if err == nil || !strings.Contains(err.Error(), "'"+repo+"'") {
+		t.Errorf("got %v, want %s used as repository", err, repo)
+	}
+}
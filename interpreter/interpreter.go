@@ -2,6 +2,7 @@ package interpreter
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -10,13 +11,14 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
-	"sync"
 	"text/template"
+	"time"
 
 	"github.com/Masterminds/sprig/v3"
 	"github.com/fasibio/gomake/command"
-	nearfinder "github.com/fasibio/gomake/nearFinder"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
 )
@@ -44,27 +46,86 @@ type TemplateData struct {
 	Vars   map[string]any
 	Env    map[string]string
 	Colors map[string]string
+	// args of an include (like {{include "deploy" (dict "env" "prod")}})
+	Args map[string]any
+	// params of the executed command (like gomake run deploy --region eu)
+	Params map[string]any
 }
 
 type Interpreter struct {
-	App            string
-	cmdHandler     command.CommandHandler
-	commandFile    []byte
-	DryRun         bool
+	App         string
+	cmdHandler  command.CommandHandler
+	commandFile []byte
+	DryRun      bool
+	Force       bool
+	Timeout     time.Duration
+	GracePeriod time.Duration
+	MaxParallel int
+	FailFast    bool
+	// run several commands of gomake run in parallel
+	Parallel bool
+	// env files given by --env-file
+	EnvFiles []string
+	// export all vars as GOMAKE_VAR_* like export_vars
+	ExportVars bool
+	// ask for missing required vars and prompt values
+	Prompt bool
+	// fail on undefined variables and template errors
+	Strict bool
+	// max age of cached remote includes
+	CacheTTL time.Duration
+	// use only cached remote includes
+	Offline        bool
+	processes      *processRegistry
 	ExecuteCommand string
 	executer       string
-	ExtraVariables map[string]string
+	ExtraVariables map[string]any
+	// arguments after the executed command: its params (--name value) and further commands with their params
+	ParamArgs []string
+	// gomake file, only used to show where a command comes from
+	MakeFile string
+	// file of each command included by includeFile
+	commandSources map[string]string
+	// namespace of each command included by includeFile with a namespace
+	commandNamespaces map[string]string
+	// .Args while rendering for an include with args
+	templateArgs map[string]any
+	// .Params of the executed command
+	templateParams map[string]any
+	// additional environment of all scripts (env files and exported vars)
+	scriptEnv map[string]string
+	// scriptEnv of the files included by the currently rendered file
+	includedEnv map[string]string
+	secrets     *secretRegistry
+	// answers of prompt by message
+	promptAnswers map[string]string
+	// strict of the file which includes the currently rendered file
+	renderStrict bool
+	// gomake.lock, loaded on first use
+	lock *LockFile
+	// source of each line of the last rendered gomake file
+	sourceMap sourceMap
+	// content of each rendered file, for the snippets of errors
+	sourceTexts map[string]string
 }
 
 func NewInterpreter(appName, executeCommand, executer string, dryRun bool, cmdHandler command.CommandHandler, commandFile []byte) Interpreter {
 	return Interpreter{
-		App:            appName,
-		cmdHandler:     cmdHandler,
-		commandFile:    commandFile,
-		DryRun:         dryRun,
-		ExecuteCommand: executeCommand,
-		executer:       executer,
-		ExtraVariables: make(map[string]string),
+		App:               appName,
+		cmdHandler:        cmdHandler,
+		commandFile:       commandFile,
+		DryRun:            dryRun,
+		ExecuteCommand:    executeCommand,
+		executer:          executer,
+		ExtraVariables:    make(map[string]any),
+		GracePeriod:       10 * time.Second,
+		processes:         newProcessRegistry(),
+		commandSources:    make(map[string]string),
+		commandNamespaces: make(map[string]string),
+		sourceTexts:       make(map[string]string),
+		secrets:           newSecretRegistry(),
+		promptAnswers:     make(map[string]string),
+		CacheTTL:          DefaultCacheTTL,
 	}
 }
 
@@ -78,27 +139,103 @@ func (r *Interpreter) GetMakeScripts() (command.MakeStruct, error) {
 func (r *Interpreter) getMakeScripts(yamlFileData []byte) (command.MakeStruct, error) {
 	var c1 command.MakeStruct
 	err := yaml.Unmarshal(yamlFileData, &c1)
+	if err != nil {
+		err = r.addSnippet(toYamlError(err, r.sourceMap.locate))
+	}
+	r.resolveNamespaces(c1)
+	r.cmdHandler.SetArgsRenderer(r.renderWithArgs)
+	r.cmdHandler.SetMasker(r.secrets.mask)
 	return c1, err
 }
 
-func (r *Interpreter) GetExecuteTemplate(file string, extraVariables map[string]any) ([]byte, map[string]map[string]any, error) {
-	varCommandArr := strings.Split(file, "---")
-	if len(varCommandArr) == 1 {
-		varCommandArr = strings.Split("vars:\n---\n"+file, "---")
+// renderWithArgs renders the gomake file again with args as .Args for includes with args
+func (r *Interpreter) renderWithArgs(args map[string]any) (command.MakeStruct, error) {
+	oldArgs := r.templateArgs
+	r.templateArgs = args
+	defer func() {
+		r.templateArgs = oldArgs
+	}()
+	explizitMakeFile, _, err := r.GetExecuteTemplate(string(r.commandFile), make(map[string]any))
+	if err != nil {
+		return nil, err
 	}
-	if len(varCommandArr) != 2 {
-		return []byte{}, nil, fmt.Errorf("only variables and command as seperated yaml are allowed")
+	return r.getMakeScripts(explizitMakeFile)
+}
+
+// VarsDocument is the first yaml document of a gomake file
+type VarsDocument struct {
+	Vars map[string]any `yaml:"vars"`
+	// order of stages for pipeline
+	Stages      []string               `yaml:"stages,omitempty"`
+	StageConfig map[string]StageConfig `yaml:"stage_config,omitempty"`
+	// env files loaded into .Env and the environment of all scripts (not existing files are skipped)
+	Dotenv []string `yaml:"dotenv,omitempty"`
+	// export all vars as GOMAKE_VAR_* to the environment of all scripts
+	ExportVars bool `yaml:"export_vars,omitempty"`
+	// like vars but their values are replaced by *** at every output
+	Secrets map[string]any `yaml:"secrets,omitempty"`
+	// vars gomake asks for if they are missing
+	RequiredVars []RequiredVar `yaml:"required_vars,omitempty"`
+	// like --strict for this file and all files included by it
+	Strict bool `yaml:"strict,omitempty"`
+}
+
+var fileStrictRegex = regexp.MustCompile(`(?m)^strict:\s*true\s*$`)
+
+func (r *Interpreter) getVarsDocument(content string, data TemplateData, source templateSource, strict bool) (VarsDocument, error) {
+	varStr, m, err := r.getParsedTemplate("gomake_vars", content, data, source, strict)
+	if err != nil {
+		return VarsDocument{}, err
+	}
+	var variables VarsDocument
+	if err := yaml.Unmarshal(varStr, &variables); err != nil {
+		return VarsDocument{}, r.addSnippet(toYamlError(err, m.locate))
 	}
+	return variables, nil
+}
+
+func (r *Interpreter) GetExecuteTemplate(file string, extraVariables map[string]any) ([]byte, VarsDocument, error) {
+	b, m, variables, err := r.getExecuteTemplate(r.MakeFile, file, extraVariables)
+	r.sourceMap = m
+	return b, variables, err
+}
+
+// getExecuteTemplate renders the content of the gomake file source and returns the source of each line of the rendered commands
+func (r *Interpreter) getExecuteTemplate(source, file string, extraVariables map[string]any) ([]byte, sourceMap, VarsDocument, error) {
+	r.sourceTexts[source] = file
+	docs, err := splitDocuments(source, file)
+	if err != nil {
+		return nil, nil, VarsDocument{}, r.addSnippet(err)
+	}
+	varsSource := templateSource{file: source, offset: docs.vars.offset}
+	commandsSource := templateSource{file: source, offset: docs.commands.offset}
+	strict := r.Strict || r.renderStrict || fileStrictRegex.MatchString(docs.vars.content)
 
 	env := make(map[string]string)
 	for _, e := range os.Environ() {
 		pair := strings.Split(e, "=")
 		env[pair[0]] = pair[1]
 	}
-	tempVar := make(map[string]any)
-	for k, v := range r.ExtraVariables {
-		tempVar[k] = v
+	// variables of env files for the scripts, the environment of gomake wins over them
+	scriptEnv := make(map[string]string)
+	addEnv := func(values map[string]string) bool {
+		added := false
+		for k, v := range values {
+			if _, ok := env[k]; !ok {
+				env[k] = v
+				scriptEnv[k] = v
+				added = true
+			}
+		}
+		return added
 	}
+	envFiles, err := loadDotenvFiles(r.EnvFiles, false)
+	if err != nil {
+		return nil, nil, VarsDocument{}, err
+	}
+	addEnv(envFiles)
+	tempVar := make(map[string]any)
+	mergeVars(tempVar, r.ExtraVariables)
 
 	for k, v := range extraVariables {
 		if _, ok := tempVar[k]; !ok {
@@ -106,37 +243,79 @@ func (r *Interpreter) GetExecuteTemplate(file string, extraVariables map[string]
 		}
 	}
 
-	varStr, err := r.getParsedTemplate("gomake_vars", varCommandArr[0], TemplateData{Env: env, Vars: tempVar, Colors: getColorKeyMap()})
-
+	variables, err := r.getVarsDocument(docs.vars.content, TemplateData{Env: env, Vars: tempVar, Colors: getColorKeyMap()}, varsSource, strict)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, VarsDocument{}, err
 	}
-	var variables map[string]map[string]any
-	err = yaml.Unmarshal(varStr, &variables)
-	if err != nil {
-		return []byte{}, nil, err
+	if len(variables.Dotenv) > 0 {
+		dotenv, err := loadDotenvFiles(variables.Dotenv, true)
+		if err != nil {
+			return nil, nil, VarsDocument{}, err
+		}
+		if addEnv(dotenv) {
+			// render again to have the loaded values at .Env
+			variables, err = r.getVarsDocument(docs.vars.content, TemplateData{Env: env, Vars: tempVar, Colors: getColorKeyMap()}, varsSource, strict)
+			if err != nil {
+				return nil, nil, VarsDocument{}, err
+			}
+		}
 	}
-	for k, v := range r.ExtraVariables {
-		variables["vars"][k] = v
+	if len(variables.Vars) == 0 {
+		variables.Vars = make(map[string]any)
+	}
+	for k, v := range variables.Secrets {
+		variables.Vars[k] = v
+	}
+	mergeVars(variables.Vars, r.ExtraVariables)
+	for k := range variables.Secrets {
+		r.secrets.addValue(variables.Vars[k])
+	}
+	if err := r.checkRequiredVars(variables.RequiredVars, variables.Vars); err != nil {
+		return nil, nil, VarsDocument{}, err
 	}
 
 	for k, v := range extraVariables {
-		if _, ok := variables["vars"][k]; !ok {
-			variables["vars"][k] = v
+		if _, ok := variables.Vars[k]; !ok {
+			variables.Vars[k] = v
 		}
 	}
 
-	if len(variables["vars"]) == 0 {
-		variables["vars"] = make(map[string]any)
+	v, err := r.cmdHandler.ExecuteVariablesCommands(variables.Vars)
+	if err != nil {
+		return nil, nil, VarsDocument{}, err
 	}
 
-	v, err := r.cmdHandler.ExecuteVariablesCommands(variables["vars"])
+	args := r.templateArgs
+	if args == nil {
+		args = make(map[string]any)
+	}
+	params := r.templateParams
+	if params == nil {
+		params = make(map[string]any)
+	}
+	// included files inherit strict
+	oldStrict, oldIncludedEnv := r.renderStrict, r.includedEnv
+	r.renderStrict, r.includedEnv = strict, make(map[string]string)
+	b, m, err := r.getParsedTemplate("gomake", docs.commands.content, TemplateData{Vars: v, Env: env, Colors: getColorKeyMap(), Args: args, Params: params}, commandsSource, strict)
+	includedEnv := r.includedEnv
+	r.renderStrict, r.includedEnv = oldStrict, oldIncludedEnv
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, VarsDocument{}, err
 	}
-
-	b, err := r.getParsedTemplate("gomake", varCommandArr[1], TemplateData{Vars: v, Env: env, Colors: getColorKeyMap()})
-	return b, variables, err
+	// env files of included files win over the ones of this file (like their vars)
+	for k, value := range includedEnv {
+		scriptEnv[k] = value
+	}
+	if err := checkRenderedDocument(b, m); err != nil {
+		return nil, nil, VarsDocument{}, r.addSnippet(err)
+	}
+	if r.ExportVars || variables.ExportVars {
+		for k, value := range r.getExportedVars(v) {
+			scriptEnv[k] = value
+		}
+	}
+	r.scriptEnv = scriptEnv
+	return b, m, variables, nil
 }
 
 func (r *Interpreter) Run() error {
@@ -149,36 +328,171 @@ func (r *Interpreter) Run() error {
 		return err
 	}
 
-	if _, ok := c1[r.ExecuteCommand]; !ok {
-		return fmt.Errorf("command %s not exist at makefile, did you mean \n%s", r.ExecuteCommand, nearfinder.ClosestMatch(r.ExecuteCommand, nearfinder.GetKeysOfMap(c1), 2))
+	prepared := make([][]StageOperationWrapper, 0)
+	dryRunCommands := make([]StageOperationWrapper, 0)
+	dryRunVariables := variables
+	for i, request := range splitRunArgs(r.ExecuteCommand, r.ParamArgs, c1) {
+		commands, commandVariables, err := r.prepareRun(request, c1, variables)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			dryRunVariables = commandVariables
+		}
+		prepared = append(prepared, commands)
+		dryRunCommands = append(dryRunCommands, commands...)
+	}
+	if r.DryRun {
+		return r.printDryRun(dryRunCommands, dryRunVariables)
+	}
+
+	defer r.handleSignals()()
+	if len(prepared) == 1 {
+		return r.runCommand(prepared[0])
+	}
+	if r.Parallel {
+		return r.runParallelCommands(prepared)
+	}
+	return r.runSequential(prepared)
+}
+
+// runOperation executes the script of the operation and on error its on_failure scripts.
+// An attached operation gets the terminal, otherwise its output is prefixed with the name of the operation.
+func (r *Interpreter) runOperation(ctx context.Context, operator StageOperationWrapper, attached bool) error {
+	err := r.runScript(ctx, operator, attached)
+	if err != nil && !isNotStarted(err) {
+		if cleanupErr := r.runCleanup(operator, attached, err); cleanupErr != nil {
+			err = errors.Wrap(err, cleanupErr.Error())
+		}
 	}
+	return err
+}
 
-	command, err := r.cmdHandler.GetExecutedCommandMakeScript(r.ExecuteCommand, c1)
+// runScript executes the script of the operation, it is skipped if sources and rendered script are unchanged since the last successful run
+// A done ctx cancels the script.
+func (r *Interpreter) runScript(ctx context.Context, operator StageOperationWrapper, attached bool) error {
+	writer := operator.getWriter(attached)
+	if r.processes.isInterrupted() {
+		return errNotStarted
+	}
+	if ctx.Err() != nil {
+		return errSkipped
+	}
+	fingerprint, upToDate, err := r.isUpToDate(operator)
 	if err != nil {
 		return err
 	}
-	if r.DryRun {
-		return r.printDryRun([]StageOperationWrapper{{Name: r.ExecuteCommand, Command: command[r.ExecuteCommand]}}, variables)
+	if upToDate {
+		fmt.Fprintf(writer, "%s skipped (up to date)\n", operator.Name)
+		return nil
 	}
-
-	cmd := r.cmdHandler.SliceCommands(command[r.ExecuteCommand].Script)
-	executer := r.executer
-	if image := command[r.ExecuteCommand].Image; image != nil {
-		cmd = getDockerCmd(cmd, image)
+	err = r.retry(ctx, operator, func() error {
+		return r.execScript(ctx, operator, attached)
+	})
+	if fingerprint != "" {
+		if err != nil {
+			removeFingerprint(operator.Name)
+		} else if saveErr := saveFingerprint(operator.Name, fingerprint); saveErr != nil {
+			fmt.Fprintf(writer, "Can not save fingerprint: %s\n", saveErr)
+		}
 	}
+	return err
+}
 
-	err = r.execCmd(executer, cmd, log.Writer())
+// execScript executes the script of the operation once, limited by its timeout
+func (r *Interpreter) execScript(ctx context.Context, operator StageOperationWrapper, attached bool) error {
+	writer := operator.getWriter(attached)
+	timeout, err := r.getTimeout(operator.Command)
 	if err != nil {
-		if len(command[r.ExecuteCommand].On_Failure) > 0 {
-			fmt.Println("Script end with error so start onFailure Scripts ...")
-			err = r.execCmd(executer, r.cmdHandler.SliceCommands(command[r.ExecuteCommand].On_Failure), log.Writer())
-		} else {
-			fmt.Println("No onFailure Scripts found but got error")
+		return err
+	}
+
+	cmd := r.cmdHandler.SliceCommands(operator.Command.Script)
+	options := operator.getExecOptions(attached, r.scriptEnv)
+	container := ""
+	if image := operator.Command.Image; image != nil {
+		env := make(map[string]string)
+		for k, v := range r.scriptEnv {
+			env[k] = v
+		}
+		for k, v := range operator.Command.Env {
+			env[k] = v
+		}
+		container = getContainerName(operator.Name)
+		cmd = getDockerCmd(cmd, container, image, env, operator.Command.Dir, attached && isForegroundTerminal())
+		options.env = nil
+		options.dir = ""
+	}
+	timeoutCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		timeoutCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	err = r.execCmd(timeoutCtx, r.executer, cmd, options)
+	if container != "" && timeoutCtx.Err() != nil {
+		if rmErr := removeContainer(container); rmErr != nil {
+			fmt.Fprintf(writer, "Can not remove container %s: %s\n", container, rmErr)
 		}
 	}
+	if err != nil && ctx.Err() != nil && !errors.Is(err, ErrInterrupted) {
+		// ctx is only cancelled by fail fast, a signal interrupts the process itself
+		err = errCancelled
+		fmt.Fprintf(writer, "%s %s\n", operator.Name, err)
+	} else if err != nil && timeoutCtx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("%s timed out after %s", operator.Name, timeout)
+		fmt.Fprintln(writer, err)
+	}
 	return err
 }
 
+// runCleanup executes the on_interrupt scripts for interrupted operations otherwise the on_failure scripts.
+// Operations cancelled by fail fast did not fail, so only their on_interrupt scripts are executed.
+func (r *Interpreter) runCleanup(operator StageOperationWrapper, attached bool, err error) error {
+	if err == errCancelled {
+		if len(operator.Command.On_Interrupt) == 0 {
+			return nil
+		}
+		return r.runOnInterrupt(operator, attached)
+	}
+	if errors.Is(err, ErrInterrupted) {
+		return r.runOnInterrupt(operator, attached)
+	}
+	return r.runOnFailure(operator, attached)
+}
+
+// runOnInterrupt falls back to the on_failure scripts if there are no on_interrupt scripts
+func (r *Interpreter) runOnInterrupt(operator StageOperationWrapper, attached bool) error {
+	if len(operator.Command.On_Interrupt) == 0 {
+		return r.runOnFailure(operator, attached)
+	}
+	writer := operator.getWriter(attached)
+	fmt.Fprintln(writer, "Script was interrupted so start onInterrupt Scripts ...")
+	return r.execCmd(context.Background(), r.executer, r.cmdHandler.SliceCommands(operator.Command.On_Interrupt), operator.getExecOptions(attached, r.scriptEnv))
+}
+
+func (r *Interpreter) runOnFailure(operator StageOperationWrapper, attached bool) error {
+	writer := operator.getWriter(attached)
+	if len(operator.Command.On_Failure) == 0 {
+		fmt.Fprintln(writer, "No onFailure Scripts found but got error")
+		return nil
+	}
+	fmt.Fprintln(writer, "Script end with error so start onFailure Scripts ...")
+	return r.execCmd(context.Background(), r.executer, r.cmdHandler.SliceCommands(operator.Command.On_Failure), operator.getExecOptions(attached, r.scriptEnv))
+}
+
+// getTimeout returns the timeout of the operation or if not set the global one
+func (r *Interpreter) getTimeout(operation command.Operation) (time.Duration, error) {
+	if operation.Timeout == "" {
+		return r.Timeout, nil
+	}
+	timeout, err := time.ParseDuration(operation.Timeout)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid timeout %s", operation.Timeout)
+	}
+	return timeout, nil
+}
+
 type StageOperationWrapper struct {
 	Name    string
 	Command command.Operation
@@ -189,6 +503,43 @@ type StageOperationWrapperError struct {
 	error
 }
 
+// execOptions describe how the process of a script is started
+type execOptions struct {
+	writer   io.Writer
+	attached bool
+	// nil means the environment of gomake
+	env []string
+	dir string
+}
+
+// getExecOptions returns the options to execute scripts of the operation at the host.
+// scriptEnv is added to the environment before the env of the operation.
+func (w *StageOperationWrapper) getExecOptions(attached bool, scriptEnv map[string]string) execOptions {
+	res := execOptions{
+		writer:   w.getWriter(attached),
+		attached: attached,
+		dir:      w.Command.Dir,
+	}
+	if len(w.Command.Env) > 0 || len(scriptEnv) > 0 {
+		res.env = os.Environ()
+		for _, k := range getSortedKeys(scriptEnv) {
+			res.env = append(res.env, fmt.Sprintf("%s=%s", k, scriptEnv[k]))
+		}
+		for _, k := range getSortedKeys(w.Command.Env) {
+			res.env = append(res.env, fmt.Sprintf("%s=%s", k, w.Command.Env[k]))
+		}
+	}
+	return res
+}
+
+// getWriter returns the log writer for attached operations otherwise the operation itself to prefix the output
+func (w *StageOperationWrapper) getWriter(attached bool) io.Writer {
+	if attached {
+		return log.Writer()
+	}
+	return w
+}
+
 func (w *StageOperationWrapper) Write(data []byte) (n int, err error) {
 
 	colorFunc := func(d ...interface{}) string { return fmt.Sprint(d...) }
@@ -209,14 +560,14 @@ func (w *StageOperationWrapper) Color(colorString string) func(...interface{}) s
 	return sprint
 }
 
-func (r *Interpreter) GetStageMap() (map[string][]StageOperationWrapper, command.MakeStruct, map[string]map[string]any, error) {
+func (r *Interpreter) GetStageMap() (map[string][]StageOperationWrapper, command.MakeStruct, VarsDocument, error) {
 	explizitMakeFile, variables, err := r.GetExecuteTemplate(string(r.commandFile), make(map[string]any))
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, VarsDocument{}, err
 	}
 	c1, err := r.getMakeScripts(explizitMakeFile)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, VarsDocument{}, err
 	}
 	stagesMap := make(map[string][]StageOperationWrapper)
 	for k, c := range c1 {
@@ -228,6 +579,11 @@ func (r *Interpreter) GetStageMap() (map[string][]StageOperationWrapper, command
 			stagesMap[c.Stage] = append(stagesMap[c.Stage], StageOperationWrapper{Name: k, Command: c})
 		}
 	}
+	for _, commands := range stagesMap {
+		sort.Slice(commands, func(i, j int) bool {
+			return commands[i].Name < commands[j].Name
+		})
+	}
 	return stagesMap, c1, variables, nil
 }
 
@@ -238,103 +594,116 @@ func (r *Interpreter) SRun() error {
 		return err
 	}
 
-	if _, ok := stagesMap[r.ExecuteCommand]; !ok {
-		return fmt.Errorf("no command with stage %s found at makefile, did you mean \n%s", r.ExecuteCommand, nearfinder.ClosestMatch(r.ExecuteCommand, nearfinder.GetKeysOfMap(stagesMap), 2))
-	}
-
-	commands := make([]StageOperationWrapper, 0)
-	for _, c := range stagesMap[r.ExecuteCommand] {
-		tmpc, err := r.cmdHandler.GetExecutedCommandMakeScript(c.Name, c1)
-		if err != nil {
-			return err
-		}
-		commands = append(commands, StageOperationWrapper{Name: c.Name, Command: tmpc[c.Name]})
+	commands, err := r.getStageCommands(r.ExecuteCommand, stagesMap, c1)
+	if err != nil {
+		return err
 	}
 
 	if r.DryRun {
 		return r.printDryRun(commands, variables)
 	}
-	w := sync.WaitGroup{}
-	errList := make([]StageOperationWrapperError, 0)
-	for _, c := range commands {
-		w.Add(1)
-		go func(operator StageOperationWrapper) {
-			cmd := r.cmdHandler.SliceCommands(operator.Command.Script)
-			if image := operator.Command.Image; image != nil {
-				cmd = getDockerCmd(cmd, image)
-			}
-			err := r.execCmd(r.executer, cmd, &operator)
-			if err != nil {
-				errList = append(errList, StageOperationWrapperError{
-					error:                 err,
-					StageOperationWrapper: operator,
-				})
-			}
-			w.Done()
-		}(c)
-	}
-	w.Wait()
-	var errres error = nil
-	if len(errList) > 0 {
-		for _, e := range errList {
-			if len(e.Command.On_Failure) > 0 {
-				e.Write([]byte(fmt.Sprintf("%s end with error so start onFailure Scripts ...", e.Name)))
-				err := r.execCmd(r.executer, r.cmdHandler.SliceCommands(e.Command.On_Failure), &e)
-				if err != nil {
-					errres = errors.Wrap(errres, err.Error())
-				}
-			} else {
-				e.Write([]byte(fmt.Sprintf("No OnFailer Script found but %s got error ", e.Name)))
-			}
-		}
-	}
-	return errres
+
+	defer r.handleSignals()()
+	return r.runStage(r.ExecuteCommand, commands, r.getStageConfig(r.ExecuteCommand, variables))
 }
 
-func (r *Interpreter) execCmd(executer, command string, writer io.Writer) error {
+// execCmd runs the command inside its own process group, the whole group is killed if ctx is done before.
+// The process group gets forwarded signals while it is running.
+func (r *Interpreter) execCmd(ctx context.Context, executer, command string, options execOptions) error {
 	cmd := exec.Command(executer, "-c", command)
-	cmd.Stdin = os.Stdin
+	terminal := isForegroundTerminal()
+	if options.attached || !terminal {
+		cmd.Stdin = os.Stdin
+	}
+	writer := options.writer
+	if !r.secrets.isEmpty() {
+		masked := &maskWriter{w: writer, secrets: r.secrets}
+		defer masked.Flush()
+		writer = masked
+	}
 	cmd.Stdout = writer
 	cmd.Stderr = writer
-	return cmd.Run()
+	cmd.Env = options.env
+	cmd.Dir = options.dir
+	foreground := options.attached && terminal
+	setProcessGroup(cmd, foreground)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if foreground {
+		defer restoreForeground()
+	}
+	r.processes.add(cmd)
+
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- cmd.Wait()
+	}()
+	select {
+	case err := <-waitErr:
+		signaled := r.processes.remove(cmd)
+		if err != nil && (signaled || isInterruptExit(err)) {
+			r.processes.setInterrupted()
+			return errors.Wrap(ErrInterrupted, err.Error())
+		}
+		return err
+	case <-ctx.Done():
+		killProcessGroup(cmd)
+		<-waitErr
+		r.processes.remove(cmd)
+		return ctx.Err()
+	}
 }
 
-func (r *Interpreter) getParsedTemplate(templateName, tmpl string, data TemplateData) ([]byte, error) {
+// getParsedTemplate executes the template and returns the source of each rendered line, errors have the file and line of source.
+// With strict undefined variables and errors of the execution fail.
+func (r *Interpreter) getParsedTemplate(templateName, tmpl string, data TemplateData, source templateSource, strict bool) ([]byte, sourceMap, error) {
 	t := template.New(templateName)
 	var buf bytes.Buffer
 
 	funcMap := r.cmdHandler.GetFuncMap()
-	funcMap["shell"] = func(cmd string) string {
-		cmdRunner := exec.Command("/bin/sh", "-c", cmd)
-		buf := new(bytes.Buffer)
-		cmdRunner.Stdout = buf
-		cmdRunner.Stderr = log.Writer()
-
-		err := cmdRunner.Run()
+	funcMap["shell"] = runShell
+	funcMap["shellOr"] = runShellOr
+	funcMap["prompt"] = r.prompt
+	funcMap["secret"] = func(value any) any {
+		r.secrets.addValue(value)
+		return value
+	}
+	funcMap["includeFile"] = func(name string, options ...any) (string, error) {
+		opts, err := getIncludeFileOptions(options)
 		if err != nil {
-			log.Panic(err)
+			return "", templateFuncError{errors.Wrapf(err, "includeFile %s", name)}
 		}
-		return buf.String()
-	}
-	funcMap["includeFile"] = func(name string) string {
-		files, err := GetContents(name)
+		files, err := r.getIncludeFileContents(name, opts)
 		if err != nil {
-			log.Panic(err)
+			return "", err
 		}
 		res := strings.Builder{}
 
 		for _, f := range files {
-			b, variables, err := r.GetExecuteTemplate(string(f), data.Vars)
+			b, m, variables, err := r.getExecuteTemplate(f.Source, f.Content, data.Vars)
 			if err != nil {
-				log.Panic(err)
+				return "", templateFuncError{errors.Wrapf(err, "includeFile %s", name)}
+			}
+			if opts.namespace != "" {
+				if b, err = r.addNamespace(b, opts.namespace); err != nil {
+					return "", templateFuncError{errors.Wrapf(err, "includeFile %s", name)}
+				}
 			}
-			for k, v := range variables["vars"] {
+			r.setCommandSources(b, f.Source)
+			for k, v := range variables.Vars {
 				data.Vars[k] = v
 			}
-			res.Write(b)
+			// nil while rendering the vars document
+			if r.includedEnv != nil {
+				for k, v := range r.scriptEnv {
+					r.includedEnv[k] = v
+				}
+			}
+			res.Write(addSourceMarkers(b, m))
 			res.WriteString("\n")
 		}
-		return res.String()
+		return res.String(), nil
 	}
 	sprigFunc := sprig.FuncMap()
 	for k, v := range sprigFunc {
@@ -343,25 +712,61 @@ func (r *Interpreter) getParsedTemplate(templateName, tmpl string, data Template
 
 	t, err := t.Funcs(funcMap).Parse(tmpl)
 	if err != nil {
-		return []byte{}, err
+		return []byte{}, nil, r.addSnippet(toTemplateError(err, source))
+	}
+	// includeFile adds the vars of the included files while the template is executed, so they are checked afterwards
+	checkAfterExecute := strict && callsTemplateFunc(t, "includeFile")
+	if strict && !checkAfterExecute {
+		t.Option("missingkey=error")
+		if err := checkTemplateRefs(t, &data, source); err != nil {
+			return nil, nil, r.addSnippet(err)
+		}
 	}
-	t.Execute(&buf, data)
-	return buf.Bytes(), nil
+	markSourceLines(t, tmpl, source)
+	err = t.Execute(&buf, data)
+	if checkAfterExecute && !isTemplateFuncError(err) {
+		if refsErr := checkTemplateRefs(t, &data, source); refsErr != nil {
+			return nil, nil, r.addSnippet(refsErr)
+		}
+	}
+	if err != nil && (strict || isTemplateFuncError(err)) {
+		return nil, nil, r.addSnippet(toTemplateError(err, source))
+	}
+	b, m := stripSourceMarkers(buf.Bytes(), source)
+	return b, m, nil
 }
 
-func GetContents(path string) ([]string, error) {
-	var contents []string
-	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
-		resp, err := http.Get(path)
-		if err != nil {
-			return nil, err
-		}
-		defer resp.Body.Close()
-		bytes, err := ioutil.ReadAll(resp.Body)
+// FileContent is the content of a file read by includeFile
+type FileContent struct {
+	// path or url of the file
+	Source  string
+	Content string
+}
+
+// httpStatusError is returned by GetContents if the url does not respond with success
+type httpStatusError struct {
+	url    string
+	status string
+	code   int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("GET %s: %s", e.url, e.status)
+}
+
+// Is makes 404 and 410 an os.ErrNotExist like missing local files
+func (e *httpStatusError) Is(target error) bool {
+	return target == os.ErrNotExist && (e.code == http.StatusNotFound || e.code == http.StatusGone)
+}
+
+func GetContents(path string) ([]FileContent, error) {
+	var contents []FileContent
+	if isRemotePath(path) {
+		content, err := download(path)
 		if err != nil {
 			return nil, err
 		}
-		contents = append(contents, string(bytes))
+		contents = append(contents, FileContent{Source: path, Content: content})
 	} else if strings.Contains(path, "*") {
 		files, err := filepath.Glob(path)
 		if err != nil {
@@ -372,14 +777,14 @@ func GetContents(path string) ([]string, error) {
 			if err != nil {
 				return nil, err
 			}
-			contents = append(contents, string(bytes))
+			contents = append(contents, FileContent{Source: file, Content: string(bytes)})
 		}
 	} else {
 		bytes, err := ioutil.ReadFile(path)
 		if err != nil {
 			return nil, err
 		}
-		contents = append(contents, string(bytes))
+		contents = append(contents, FileContent{Source: path, Content: string(bytes)})
 	}
 	return contents, nil
 }
@@ -0,0 +1,122 @@
+package interpreter
+
+import (
+	"sort"
+
+	"github.com/fasibio/gomake/command"
+	"gopkg.in/yaml.v2"
+)
+
+type CommandInfo struct {
+	Name string `json:"name" yaml:"name"`
+	// prefix of commands included by includeFile with a namespace
+	Namespace string      `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Doc       string      `json:"doc,omitempty" yaml:"doc,omitempty"`
+	Stage     string      `json:"stage,omitempty" yaml:"stage,omitempty"`
+	Image     string      `json:"image,omitempty" yaml:"image,omitempty"`
+	Color     string      `json:"color,omitempty" yaml:"color,omitempty"`
+	Needs     []string    `json:"needs,omitempty" yaml:"needs,omitempty"`
+	Includes  []string    `json:"includes,omitempty" yaml:"includes,omitempty"`
+	Params    []ParamInfo `json:"params,omitempty" yaml:"params,omitempty"`
+	Source    string      `json:"source" yaml:"source"`
+}
+
+type ParamInfo struct {
+	Name        string   `json:"name" yaml:"name"`
+	Type        string   `json:"type" yaml:"type"`
+	Default     string   `json:"default,omitempty" yaml:"default,omitempty"`
+	Required    bool     `json:"required,omitempty" yaml:"required,omitempty"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Enum        []string `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Usage       string   `json:"usage" yaml:"usage"`
+}
+
+type StageInfo struct {
+	Name     string   `json:"name" yaml:"name"`
+	Commands []string `json:"commands" yaml:"commands"`
+}
+
+// CommandList describes all commands (grouped by namespace) and stages of a gomake file sorted by name
+type CommandList struct {
+	Commands []CommandInfo `json:"commands" yaml:"commands"`
+	Stages   []StageInfo   `json:"stages" yaml:"stages"`
+}
+
+// setCommandSources remembers source as file of all commands at yamlFileData.
+// Commands of nested includeFile calls are already known, so they keep their source.
+func (r *Interpreter) setCommandSources(yamlFileData []byte, source string) {
+	var makefile command.MakeStruct
+	if err := yaml.Unmarshal(yamlFileData, &makefile); err != nil {
+		return
+	}
+	for name := range makefile {
+		if _, ok := r.commandSources[name]; !ok {
+			r.commandSources[name] = source
+		}
+	}
+}
+
+func (r *Interpreter) getCommandSource(name string) string {
+	if source, ok := r.commandSources[name]; ok {
+		return source
+	}
+	return r.MakeFile
+}
+
+func (r *Interpreter) GetCommandList() (CommandList, error) {
+	stagesMap, makefile, _, err := r.GetStageMap()
+	if err != nil {
+		return CommandList{}, err
+	}
+	res := CommandList{Commands: make([]CommandInfo, 0), Stages: make([]StageInfo, 0)}
+	for name, operation := range makefile {
+		info := CommandInfo{
+			Name:      name,
+			Namespace: r.getCommandNamespace(name),
+			Doc:       operation.Doc,
+			Stage:     operation.Stage,
+			Color:     operation.Color,
+			Needs:     operation.Needs,
+			Includes:  r.cmdHandler.GetIncludes(operation),
+			Source:    r.getCommandSource(name),
+		}
+		if operation.Image != nil {
+			info.Image = operation.Image.Name
+		}
+		for _, p := range operation.Params {
+			paramType := p.Type
+			if paramType == "" {
+				paramType = ParamTypeString
+			}
+			info.Params = append(info.Params, ParamInfo{
+				Name:        p.Name,
+				Type:        paramType,
+				Default:     p.Default,
+				Required:    p.Required,
+				Description: p.Description,
+				Enum:        p.Enum,
+				Usage:       ParamUsage(p),
+			})
+		}
+		res.Commands = append(res.Commands, info)
+	}
+	// grouped by namespace, commands without one first
+	sort.Slice(res.Commands, func(i, j int) bool {
+		if res.Commands[i].Namespace != res.Commands[j].Namespace {
+			return res.Commands[i].Namespace < res.Commands[j].Namespace
+		}
+		return res.Commands[i].Name < res.Commands[j].Name
+	})
+
+	for stage, commands := range stagesMap {
+		info := StageInfo{Name: stage, Commands: make([]string, 0)}
+		for _, c := range commands {
+			info.Commands = append(info.Commands, c.Name)
+		}
+		res.Stages = append(res.Stages, info)
+	}
+	sort.Slice(res.Stages, func(i, j int) bool {
+		return res.Stages[i].Name < res.Stages[j].Name
+	})
+	return res, nil
+}
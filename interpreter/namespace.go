@@ -0,0 +1,123 @@
+package interpreter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fasibio/gomake/command"
+	"gopkg.in/yaml.v2"
+)
+
+const NamespaceSeparator = ":"
+
+var (
+	namespaceRegex = regexp.MustCompile(`^[0-9a-zA-Z_-]+$`)
+	// top level keys are the only lines starting at column 0 (plain, double or single quoted)
+	topLevelKeyRegex = regexp.MustCompile(`(?m)^("(?:[^"\\]|\\.)*"|'(?:[^']|'')*'|[^\s"'#][^\n]*?)(\s*:(?:\s|$))`)
+)
+
+func validateNamespace(namespace string) error {
+	if !namespaceRegex.MatchString(namespace) {
+		return fmt.Errorf("invalid namespace %q, only letters, digits, _ and - are allowed", namespace)
+	}
+	return nil
+}
+
+// addNamespace prefixes all commands of the rendered yaml with namespace (like ns:build).
+// Only the keys are renamed at the text, so the yaml keeps its meaning and lines.
+func (r *Interpreter) addNamespace(content []byte, namespace string) ([]byte, error) {
+	var commands yaml.MapSlice
+	if err := yaml.Unmarshal(content, &commands); err != nil {
+		return nil, err
+	}
+	newNames := make(map[string]string)
+	for _, c := range commands {
+		if name, ok := c.Key.(string); ok {
+			newNames[name] = namespace + NamespaceSeparator + name
+		}
+	}
+	// all keys are renamed at once, so a new name is never renamed again (like ns:a of a command ns)
+	content = topLevelKeyRegex.ReplaceAllFunc(content, func(match []byte) []byte {
+		m := topLevelKeyRegex.FindSubmatch(match)
+		newName, ok := newNames[unquoteYamlKey(string(m[1]))]
+		if !ok {
+			return match
+		}
+		return append([]byte(strconv.Quote(newName)), m[2]...)
+	})
+
+	for _, c := range commands {
+		name, ok := c.Key.(string)
+		if !ok {
+			continue
+		}
+		newName := newNames[name]
+		// commands of nested namespaced includes keep their source and get the outer namespace
+		if source, ok := r.commandSources[name]; ok {
+			r.commandSources[newName] = source
+		}
+		if inner, ok := r.commandNamespaces[name]; ok {
+			r.commandNamespaces[newName] = namespace + NamespaceSeparator + inner
+		} else {
+			r.commandNamespaces[newName] = namespace
+		}
+	}
+	return content, nil
+}
+
+// unquoteYamlKey returns the key of a double or single quoted yaml key, plain keys are returned unchanged
+func unquoteYamlKey(key string) string {
+	switch {
+	case strings.HasPrefix(key, `"`):
+		if res, err := strconv.Unquote(key); err == nil {
+			return res
+		}
+	case strings.HasPrefix(key, "'"):
+		return strings.ReplaceAll(strings.Trim(key, "'"), "''", "'")
+	}
+	return key
+}
+
+// resolveNamespaces renames needs and includes of namespaced commands which refer to commands of their namespace
+// (like needs: [gen] of ns:build to ns:gen), all other references stay global
+func (r *Interpreter) resolveNamespaces(makefile command.MakeStruct) {
+	for name, operation := range makefile {
+		namespace, ok := r.commandNamespaces[name]
+		if !ok {
+			continue
+		}
+		resolve := func(ref string) string {
+			candidate := namespace + NamespaceSeparator + ref
+			if _, ok := makefile[candidate]; !ok {
+				return ref
+			}
+			if ns := r.commandNamespaces[candidate]; ns != namespace && !strings.HasPrefix(ns, namespace+NamespaceSeparator) {
+				return ref
+			}
+			return candidate
+		}
+		for i, need := range operation.Needs {
+			operation.Needs[i] = resolve(need)
+		}
+		for _, list := range [][]string{operation.Script, operation.On_Failure, operation.On_Interrupt} {
+			for i, line := range list {
+				list[i] = r.cmdHandler.RenameInclude(line, resolve)
+			}
+		}
+		makefile[name] = operation
+	}
+}
+
+func (r *Interpreter) getCommandNamespace(name string) string {
+	return r.commandNamespaces[name]
+}
+
+// getNameAtSource returns the name of the command at the file defining it (without its namespace)
+func (r *Interpreter) getNameAtSource(name string) string {
+	if namespace, ok := r.commandNamespaces[name]; ok {
+		return strings.TrimPrefix(name, namespace+NamespaceSeparator)
+	}
+	return name
+}
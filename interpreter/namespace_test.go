@@ -0,0 +1,63 @@
+package interpreter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fasibio/gomake/command"
+	"gopkg.in/yaml.v2"
+)
+
+func TestAddNamespace(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "plain keys",
+			content: "build:\n  script:\n    - echo build\ntest:\n  script:\n    - echo test\n",
+			want:    []string{"ns:build", "ns:test"},
+		},
+		{
+			name:    "quoted keys",
+			content: "\"build\":\n  script:\n    - echo build\n'test' :\n  script:\n    - echo test\n",
+			want:    []string{"ns:build", "ns:test"},
+		},
+		{
+			name:    "command named like the namespace",
+			content: "a:\n  script:\n    - echo a\nns:\n  script:\n    - echo ns\n",
+			want:    []string{"ns:a", "ns:ns"},
+		},
+		{
+			name:    "command named like a renamed command",
+			content: "\"ns:a\":\n  script:\n    - echo ns:a\na:\n  script:\n    - echo a\n",
+			want:    []string{"ns:ns:a", "ns:a"},
+		},
+		{
+			name:    "nested keys and scripts stay",
+			content: "a:\n  needs: [b]\n  script:\n    - 'b: c'\nb:\n  script:\n    - echo b\n",
+			want:    []string{"ns:a", "ns:b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewInterpreter("gomake", "", "sh", false, command.NewCommandHandler("gomake", 0), nil)
+			content, err := r.addNamespace([]byte(tt.content), "ns")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var commands yaml.MapSlice
+			if err := yaml.Unmarshal(content, &commands); err != nil {
+				t.Fatalf("%s:\n%s", err, content)
+			}
+			got := make([]string, 0)
+			for _, c := range commands {
+				got = append(got, c.Key.(string))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v\n%s", got, tt.want, content)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/fasibio/gomake/command"
+	nearfinder "github.com/fasibio/gomake/nearFinder"
+)
+
+const (
+	needsUnvisited = iota
+	needsVisiting
+	needsVisited
+)
+
+// getNeedsOrder returns the command and all of its (transitive) needs in topological order.
+// The given command is always the last entry.
+func getNeedsOrder(name string, makefile command.MakeStruct) ([]string, error) {
+	order := make([]string, 0)
+	state := make(map[string]int)
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		path = append(path, name)
+		switch state[name] {
+		case needsVisiting:
+			for i, p := range path {
+				if p == name {
+					return fmt.Errorf("needs cycle: %s", strings.Join(path[i:], " -> "))
+				}
+			}
+		case needsVisited:
+			return nil
+		}
+		state[name] = needsVisiting
+		for _, need := range makefile[name].Needs {
+			if _, ok := makefile[need]; !ok {
+				return fmt.Errorf("%s needs %s but it not exist at makefile, did you mean \n%s", name, need, nearfinder.ClosestMatch(need, nearfinder.GetKeysOfMap(makefile), 2))
+			}
+			if err := visit(need, path); err != nil {
+				return err
+			}
+		}
+		state[name] = needsVisited
+		order = append(order, name)
+		return nil
+	}
+
+	if err := visit(name, make([]string, 0)); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// runNeeds executes every command exactly once as soon as all of its needs are done.
+// Independent commands run in parallel, a failed command skips everything depending on it.
+func (r *Interpreter) runNeeds(commands []StageOperationWrapper) error {
+	done := make(map[string]chan struct{})
+	for _, c := range commands {
+		done[c.Name] = make(chan struct{})
+	}
+	mu := sync.Mutex{}
+	errMap := make(map[string]error)
+	getErr := func(name string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return errMap[name]
+	}
+	setErr := func(name string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errMap[name] = err
+	}
+
+	w := sync.WaitGroup{}
+	for _, c := range commands {
+		w.Add(1)
+		go func(operator StageOperationWrapper) {
+			defer w.Done()
+			defer close(done[operator.Name])
+			for _, need := range operator.Command.Needs {
+				if _, ok := done[need]; !ok {
+					// not part of commands because it is already done
+					continue
+				}
+				<-done[need]
+				if getErr(need) != nil {
+					setErr(operator.Name, fmt.Errorf("skipped because %s failed", need))
+					return
+				}
+			}
+			if err := r.runOperation(context.Background(), operator, false); err != nil {
+				setErr(operator.Name, err)
+			}
+		}(c)
+	}
+	w.Wait()
+
+	errMsgs := make([]string, 0)
+	for _, c := range commands {
+		if err := errMap[c.Name]; err != nil {
+			errMsgs = append(errMsgs, fmt.Sprintf("%s: %s", c.Name, err))
+		}
+	}
+	if len(errMsgs) > 0 {
+		return fmt.Errorf("needs failed:\n%s", strings.Join(errMsgs, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package interpreter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fasibio/gomake/command"
+)
+
+func TestGetNeedsOrder(t *testing.T) {
+	makefile := command.MakeStruct{
+		"lint":    {},
+		"build":   {Needs: []string{"lint"}},
+		"test":    {Needs: []string{"lint"}},
+		"release": {Needs: []string{"build", "test"}},
+		"a":       {Needs: []string{"b"}},
+		"b":       {Needs: []string{"c"}},
+		"c":       {Needs: []string{"a"}},
+		"self":    {Needs: []string{"self"}},
+		"typo":    {Needs: []string{"buidl"}},
+	}
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+		wantErr string
+	}{
+		{name: "without needs", command: "lint", want: []string{"lint"}},
+		{name: "chain", command: "build", want: []string{"lint", "build"}},
+		{name: "shared need once", command: "release", want: []string{"lint", "build", "test", "release"}},
+		{name: "cycle", command: "a", wantErr: "needs cycle: a -> b -> c -> a"},
+		{name: "self cycle", command: "self", wantErr: "needs cycle: self -> self"},
+		{name: "missing need", command: "typo", wantErr: "typo needs buidl but it not exist at makefile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getNeedsOrder(tt.command, makefile)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newCountingMakefile returns commands which append their name to a file of dir each time they run
+func newCountingMakefile(dir string, needs map[string][]string) command.MakeStruct {
+	res := make(command.MakeStruct)
+	for name, n := range needs {
+		res[name] = command.Operation{Needs: n, Script: []string{"echo " + name + " >> " + filepath.Join(dir, "runs")}}
+	}
+	return res
+}
+
+func getRunCounts(t *testing.T, dir string) map[string]int {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(dir, "runs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := make(map[string]int)
+	for _, name := range strings.Fields(string(b)) {
+		res[name]++
+	}
+	return res
+}
+
+func newNeedsTestInterpreter() Interpreter {
+	return NewInterpreter("gomake", "", "sh", false, command.NewCommandHandler("gomake", 0), nil)
+}
+
+func TestRunNeedsRunsEachOnce(t *testing.T) {
+	dir := t.TempDir()
+	makefile := newCountingMakefile(dir, map[string][]string{
+		"lint":    nil,
+		"build":   {"lint"},
+		"test":    {"lint"},
+		"release": {"build", "test"},
+	})
+	order, err := getNeedsOrder("release", makefile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commands := make([]StageOperationWrapper, 0)
+	for _, name := range order {
+		commands = append(commands, StageOperationWrapper{Name: name, Command: makefile[name]})
+	}
+	r := newNeedsTestInterpreter()
+	if err := r.runNeeds(commands); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"lint": 1, "build": 1, "test": 1, "release": 1}
+	if got := getRunCounts(t, dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("got runs %v, want %v", got, want)
+	}
+}
+
+func TestRunNeedsSkipsDependentsOfFailed(t *testing.T) {
+	dir := t.TempDir()
+	makefile := newCountingMakefile(dir, map[string][]string{
+		"lint":    nil,
+		"build":   {"lint"},
+		"release": {"build"},
+	})
+	makefile["build"] = command.Operation{Needs: []string{"lint"}, Script: []string{"exit 1"}}
+	commands := make([]StageOperationWrapper, 0)
+	for _, name := range []string{"lint", "build", "release"} {
+		commands = append(commands, StageOperationWrapper{Name: name, Command: makefile[name]})
+	}
+	r := newNeedsTestInterpreter()
+	err := r.runNeeds(commands)
+	if err == nil || !strings.Contains(err.Error(), "release: skipped because build failed") {
+		t.Errorf("got %v, want release skipped because build failed", err)
+	}
+	if got := getRunCounts(t, dir); !reflect.DeepEqual(got, map[string]int{"lint": 1}) {
+		t.Errorf("got runs %v, want only lint", got)
+	}
+}
+
+func TestRunTargetsWhichAreNeedsOnce(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		dir := t.TempDir()
+		makefile := newCountingMakefile(dir, map[string][]string{
+			"a": {"b"},
+			"b": nil,
+		})
+		r := newNeedsTestInterpreter()
+		prepared := make([][]StageOperationWrapper, 0)
+		for _, name := range []string{"a", "b"} {
+			commands, _, err := r.prepareRun(runRequest{name: name}, makefile, VarsDocument{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			prepared = append(prepared, commands)
+		}
+		run := r.runSequential
+		if parallel {
+			run = r.runParallelCommands
+		}
+		if err := run(prepared); err != nil {
+			t.Fatal(err)
+		}
+		if got := getRunCounts(t, dir); !reflect.DeepEqual(got, map[string]int{"a": 1, "b": 1}) {
+			t.Errorf("parallel %t: got runs %v, want each once", parallel, got)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package interpreter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fasibio/gomake/command"
+)
+
+const (
+	ParamTypeString = "string"
+	ParamTypeInt    = "int"
+	ParamTypeFloat  = "float"
+	ParamTypeBool   = "bool"
+)
+
+var paramTypes = []string{ParamTypeString, ParamTypeInt, ParamTypeFloat, ParamTypeBool}
+
+// renderWithParams parses the params of the command from args and renders the gomake file again with them as .Params
+func (r *Interpreter) renderWithParams(name string, args []string, makefile command.MakeStruct) ([]byte, VarsDocument, command.MakeStruct, error) {
+	params, err := getParamValues(makefile[name].Params, args)
+	if err != nil {
+		return nil, VarsDocument{}, nil, fmt.Errorf("%s: %s", name, err)
+	}
+	r.templateParams = params
+	explizitMakeFile, variables, err := r.GetExecuteTemplate(string(r.commandFile), make(map[string]any))
+	if err != nil {
+		return nil, VarsDocument{}, nil, err
+	}
+	c1, err := r.getMakeScripts(explizitMakeFile)
+	return explizitMakeFile, variables, c1, err
+}
+
+// getParamValues parses args like --name value, --name=value or --flag (only bool) and checks them against params
+func getParamValues(params []command.ParamOperation, args []string) (map[string]any, error) {
+	byName := make(map[string]command.ParamOperation)
+	names := make([]string, 0)
+	for _, p := range params {
+		byName[p.Name] = p
+		names = append(names, p.Name)
+	}
+
+	given := make(map[string]string)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !isParamArg(arg) {
+			return nil, fmt.Errorf("unexpected argument %s, params are given as --name value", arg)
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		p, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown param %s%s", name, didYouMean(name, names))
+		}
+		if !hasValue {
+			switch {
+			case p.Type == ParamTypeBool && (i+1 == len(args) || !isBoolString(args[i+1])):
+				value = "true"
+			case i+1 == len(args) || isParamArg(args[i+1]):
+				return nil, fmt.Errorf("param %s needs a value", name)
+			default:
+				i++
+				value = args[i]
+			}
+		}
+		given[name] = value
+	}
+
+	res := make(map[string]any)
+	missing := make([]string, 0)
+	for _, p := range params {
+		value, ok := given[p.Name]
+		if !ok {
+			if p.Required {
+				missing = append(missing, p.Name)
+				continue
+			}
+			if p.Default == "" && p.Type != ParamTypeBool {
+				res[p.Name] = ""
+				continue
+			}
+			value = p.Default
+		}
+		v, err := getParamValue(p, value)
+		if err != nil {
+			return nil, err
+		}
+		res[p.Name] = v
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required params: %s", strings.Join(missing, ", "))
+	}
+	return res, nil
+}
+
+// getParamValue converts value to the type of the param and checks its enum
+func getParamValue(p command.ParamOperation, value string) (any, error) {
+	if len(p.Enum) > 0 && !isAtList(p.Enum, value) {
+		return nil, fmt.Errorf("param %s must be one of %s but is %s", p.Name, strings.Join(p.Enum, ", "), value)
+	}
+	switch p.Type {
+	case "", ParamTypeString:
+		return value, nil
+	case ParamTypeInt:
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("param %s must be an int but is %s", p.Name, value)
+		}
+		return v, nil
+	case ParamTypeFloat:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("param %s must be a float but is %s", p.Name, value)
+		}
+		return v, nil
+	case ParamTypeBool:
+		if value == "" {
+			return false, nil
+		}
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("param %s must be a bool but is %s", p.Name, value)
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("param %s has unknown type %s%s", p.Name, p.Type, didYouMean(p.Type, paramTypes))
+}
+
+// validateParams checks the declaration of the params of a command
+func validateParams(params []command.ParamOperation) []string {
+	problems := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, p := range params {
+		if p.Name == "" {
+			problems = append(problems, "param without name")
+			continue
+		}
+		if seen[p.Name] {
+			problems = append(problems, fmt.Sprintf("param %s is declared twice", p.Name))
+		}
+		seen[p.Name] = true
+		if p.Type != "" && !isAtList(paramTypes, p.Type) {
+			problems = append(problems, fmt.Sprintf("param %s has unknown type %s%s", p.Name, p.Type, didYouMean(p.Type, paramTypes)))
+			continue
+		}
+		if p.Default != "" || p.Type == ParamTypeBool {
+			if _, err := getParamValue(p, p.Default); err != nil {
+				problems = append(problems, fmt.Sprintf("default: %s", err))
+			}
+		}
+		if p.Required && p.Default != "" {
+			problems = append(problems, fmt.Sprintf("param %s is required and has a default", p.Name))
+		}
+	}
+	return problems
+}
+
+// ParamUsage returns a short description of the param like --replicas int (default 1)
+func ParamUsage(p command.ParamOperation) string {
+	res := "--" + p.Name
+	if p.Type != "" && p.Type != ParamTypeString {
+		res += " " + p.Type
+	}
+	if len(p.Enum) > 0 {
+		res += " " + strings.Join(p.Enum, "|")
+	}
+	if p.Required {
+		res += " (required)"
+	} else if p.Default != "" {
+		res += fmt.Sprintf(" (default %s)", p.Default)
+	}
+	if p.Description != "" {
+		res += " " + p.Description
+	}
+	return res
+}
+
+func findParam(params []command.ParamOperation, name string) (command.ParamOperation, bool) {
+	for _, p := range params {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return command.ParamOperation{}, false
+}
+
+// isParamArg reports if arg is the name of a param (like --name), negative numbers are values
+func isParamArg(arg string) bool {
+	if !strings.HasPrefix(arg, "-") {
+		return false
+	}
+	_, err := strconv.ParseFloat(arg, 64)
+	return err != nil
+}
+
+func isBoolString(value string) bool {
+	_, err := strconv.ParseBool(value)
+	return err == nil
+}
+
+func isAtList(list []string, test string) bool {
+	for _, v := range list {
+		if v == test {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package interpreter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fasibio/gomake/command"
+)
+
+var testParams = []command.ParamOperation{
+	{Name: "region", Required: true, Enum: []string{"eu", "us"}},
+	{Name: "replicas", Type: ParamTypeInt, Default: "1"},
+	{Name: "offset", Type: ParamTypeFloat},
+	{Name: "verbose", Type: ParamTypeBool},
+	{Name: "tag"},
+}
+
+func TestGetParamValues(t *testing.T) {
+	defaults := func(values map[string]any) map[string]any {
+		res := map[string]any{"region": "eu", "replicas": 1, "offset": "", "verbose": false, "tag": ""}
+		for k, v := range values {
+			res[k] = v
+		}
+		return res
+	}
+	tests := []struct {
+		name    string
+		args    []string
+		want    map[string]any
+		wantErr string
+	}{
+		{name: "value after =", args: []string{"--region=eu", "--replicas=3"}, want: defaults(map[string]any{"replicas": 3})},
+		{name: "value as next arg", args: []string{"--region", "eu", "--replicas", "3"}, want: defaults(map[string]any{"replicas": 3})},
+		{name: "single dash", args: []string{"-region", "eu"}, want: defaults(nil)},
+		{name: "bool without value", args: []string{"--verbose", "--region", "eu"}, want: defaults(map[string]any{"verbose": true})},
+		{name: "bool at the end", args: []string{"--region", "eu", "--verbose"}, want: defaults(map[string]any{"verbose": true})},
+		{name: "bool with value", args: []string{"--verbose", "false", "--region", "eu"}, want: defaults(map[string]any{"verbose": false})},
+		{name: "bool with value after =", args: []string{"--verbose=true", "--region", "eu"}, want: defaults(map[string]any{"verbose": true})},
+		{name: "negative int", args: []string{"--region", "eu", "--replicas", "-2"}, want: defaults(map[string]any{"replicas": -2})},
+		{name: "negative float", args: []string{"--region", "eu", "--offset", "-1.5"}, want: defaults(map[string]any{"offset": -1.5})},
+		{name: "negative float after =", args: []string{"--region", "eu", "--offset=-1.5"}, want: defaults(map[string]any{"offset": -1.5})},
+		{name: "value with =", args: []string{"--region", "eu", "--tag=a=b"}, want: defaults(map[string]any{"tag": "a=b"})},
+		{name: "not at enum", args: []string{"--region", "de"}, wantErr: "param region must be one of eu, us but is de"},
+		{name: "wrong int", args: []string{"--region", "eu", "--replicas", "many"}, wantErr: "param replicas must be an int but is many"},
+		{name: "wrong float", args: []string{"--region", "eu", "--offset", "x"}, wantErr: "param offset must be a float but is x"},
+		{name: "wrong bool", args: []string{"--region", "eu", "--verbose=maybe"}, wantErr: "param verbose must be a bool but is maybe"},
+		{name: "unknown param", args: []string{"--regoin", "eu"}, wantErr: "unknown param regoin, did you mean region"},
+		{name: "missing required", args: []string{"--replicas", "2"}, wantErr: "missing required params: region"},
+		{name: "missing value", args: []string{"--region"}, wantErr: "param region needs a value"},
+		{name: "param instead of value", args: []string{"--tag", "--region", "eu"}, wantErr: "param tag needs a value"},
+		{name: "argument without param", args: []string{"eu"}, wantErr: "unexpected argument eu"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getParamValues(testParams, tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got %v, %v, want error %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitRunArgs(t *testing.T) {
+	makefile := command.MakeStruct{
+		"deploy": {Params: testParams},
+		"test":   {Params: []command.ParamOperation{{Name: "short", Type: ParamTypeBool}}},
+		"lint":   {},
+	}
+	tests := []struct {
+		name  string
+		first string
+		args  []string
+		want  []runRequest
+	}{
+		{name: "only commands", first: "lint", args: []string{"test"}, want: []runRequest{{name: "lint"}, {name: "test"}}},
+		{
+			name:  "params of each command",
+			first: "test",
+			args:  []string{"--short", "deploy", "--region", "eu", "--replicas=2", "lint"},
+			want: []runRequest{
+				{name: "test", paramArgs: []string{"--short"}},
+				{name: "deploy", paramArgs: []string{"--region", "eu", "--replicas=2"}},
+				{name: "lint"},
+			},
+		},
+		{
+			name:  "bool with value",
+			first: "test",
+			args:  []string{"--short", "false", "lint"},
+			want:  []runRequest{{name: "test", paramArgs: []string{"--short", "false"}}, {name: "lint"}},
+		},
+		{
+			name:  "negative number as value",
+			first: "deploy",
+			args:  []string{"--replicas", "-2", "--offset", "-1.5", "lint"},
+			want:  []runRequest{{name: "deploy", paramArgs: []string{"--replicas", "-2", "--offset", "-1.5"}}, {name: "lint"}},
+		},
+		{
+			name:  "param at the end",
+			first: "lint",
+			args:  []string{"deploy", "--region"},
+			want:  []runRequest{{name: "lint"}, {name: "deploy", paramArgs: []string{"--region"}}},
+		},
+		{
+			name:  "unknown param takes a value",
+			first: "lint",
+			args:  []string{"--foo", "bar", "test"},
+			want:  []runRequest{{name: "lint", paramArgs: []string{"--foo", "bar"}}, {name: "test"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitRunArgs(tt.first, tt.args, makefile); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
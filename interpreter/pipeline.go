@@ -0,0 +1,61 @@
+package interpreter
+
+import (
+	"fmt"
+	"time"
+
+	nearfinder "github.com/fasibio/gomake/nearFinder"
+)
+
+// Pipeline executes all stages declared at stages of the vars document one after another.
+// Each stage runs like srun, the pipeline stops at the first failed stage.
+func (r *Interpreter) Pipeline() error {
+	stagesMap, c1, variables, err := r.GetStageMap()
+	if err != nil {
+		return err
+	}
+	if len(variables.Stages) == 0 {
+		return fmt.Errorf("no stages declared, add a list of stages (like \"stages: [lint, build]\") to the vars document")
+	}
+
+	stageCommands := make(map[string][]StageOperationWrapper)
+	for _, stage := range variables.Stages {
+		if _, ok := stagesMap[stage]; !ok {
+			return fmt.Errorf("stage %s is declared at stages but no command use it, did you mean \n%s", stage, nearfinder.ClosestMatch(stage, nearfinder.GetKeysOfMap(stagesMap), 2))
+		}
+		commands, err := r.getStageCommands(stage, stagesMap, c1)
+		if err != nil {
+			return err
+		}
+		stageCommands[stage] = commands
+	}
+
+	if r.DryRun {
+		commands := make([]StageOperationWrapper, 0)
+		for _, stage := range variables.Stages {
+			commands = append(commands, stageCommands[stage]...)
+		}
+		return r.printDryRun(commands, variables)
+	}
+
+	defer r.handleSignals()()
+	results := make([]runResult, 0)
+	var pipelineErr error
+	for _, stage := range variables.Stages {
+		if pipelineErr != nil {
+			results = append(results, runResult{name: stage, status: runStatusSkipped})
+			continue
+		}
+		fmt.Printf("Stage %s ...\n", stage)
+		start := time.Now()
+		err := r.runStage(stage, stageCommands[stage], r.getStageConfig(stage, variables))
+		result := runResult{name: stage, status: runStatusOk, duration: time.Since(start)}
+		if err != nil {
+			result.status = runStatusFailed
+			pipelineErr = err
+		}
+		results = append(results, result)
+	}
+	printSummary("Pipeline summary:", results)
+	return pipelineErr
+}
@@ -0,0 +1,66 @@
+//go:build !windows
+
+package interpreter
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"unsafe"
+
+	"github.com/pkg/errors"
+)
+
+// isForegroundTerminal reports if stdin is the controlling terminal and gomake owns its foreground
+func isForegroundTerminal() bool {
+	var pgrp int32
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, os.Stdin.Fd(), uintptr(syscall.TIOCGPGRP), uintptr(unsafe.Pointer(&pgrp)))
+	return errno == 0 && int(pgrp) == syscall.Getpgrp()
+}
+
+// setProcessGroup starts the command inside its own process group so it can be terminated with all of its children.
+// A foreground process group gets the terminal (and the terminal signals like Ctrl-C).
+func setProcessGroup(cmd *exec.Cmd, foreground bool) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if foreground {
+		cmd.SysProcAttr.Foreground = true
+		cmd.SysProcAttr.Ctty = int(os.Stdin.Fd())
+	}
+}
+
+// restoreForeground gives the terminal back to gomake after a foreground process group ends
+func restoreForeground() {
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Reset(syscall.SIGTTOU)
+	pgrp := int32(syscall.Getpgrp())
+	syscall.Syscall(syscall.SYS_IOCTL, os.Stdin.Fd(), uintptr(syscall.TIOCSPGRP), uintptr(unsafe.Pointer(&pgrp)))
+}
+
+func killProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+func signalProcessGroup(cmd *exec.Cmd, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return killProcessGroup(cmd)
+	}
+	return syscall.Kill(-cmd.Process.Pid, s)
+}
+
+// isInterruptExit reports if the process ended because of SIGINT or SIGTERM (like Ctrl-C at the terminal)
+func isInterruptExit(err error) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok {
+		return false
+	}
+	if status.Signaled() {
+		return status.Signal() == syscall.SIGINT || status.Signal() == syscall.SIGTERM
+	}
+	return status.ExitStatus() == 128+int(syscall.SIGINT)
+}
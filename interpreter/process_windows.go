@@ -0,0 +1,31 @@
+//go:build windows
+
+package interpreter
+
+import (
+	"os"
+	"os/exec"
+)
+
+// isForegroundTerminal reports if stdin is a console
+func isForegroundTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// setProcessGroup is a noop, there are no process groups at windows
+func setProcessGroup(cmd *exec.Cmd, foreground bool) {}
+
+func restoreForeground() {}
+
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+func signalProcessGroup(cmd *exec.Cmd, sig os.Signal) error {
+	return cmd.Process.Kill()
+}
+
+func isInterruptExit(err error) bool {
+	return false
+}
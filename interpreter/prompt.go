@@ -0,0 +1,180 @@
+package interpreter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var errNotInteractive = errors.New("not interactive")
+
+// RequiredVar is a var gomake asks for if it is missing, it can be given as name only
+//
+//	required_vars:
+//	  - version
+//	  - name: env
+//	    choices: [dev, prod]
+//	    default: dev
+type RequiredVar struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description,omitempty"`
+	Default     string   `yaml:"default,omitempty"`
+	Choices     []string `yaml:"choices,omitempty"`
+	// the input is not shown and the value is a secret
+	Hidden bool `yaml:"hidden,omitempty"`
+}
+
+func (v *RequiredVar) UnmarshalYAML(unmarshal func(any) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		v.Name = name
+		return nil
+	}
+	type plain RequiredVar
+	return unmarshal((*plain)(v))
+}
+
+type promptOptions struct {
+	message string
+	def     string
+	choices []string
+	hidden  bool
+}
+
+// checkRequiredVars asks for all missing required vars (only if r.Prompt is set).
+// Without a terminal defaults are used, otherwise it fails with the list of missing vars.
+func (r *Interpreter) checkRequiredVars(required []RequiredVar, vars map[string]any) error {
+	missing := make([]string, 0)
+	for _, rv := range required {
+		if v, ok := lookupVar(vars, rv.Name); ok && v != nil && fmt.Sprint(v) != "" {
+			continue
+		}
+		if !r.Prompt {
+			continue
+		}
+		if !isForegroundTerminal() {
+			if rv.Default != "" {
+				setVar(r.ExtraVariables, rv.Name, rv.Default)
+				setVar(vars, rv.Name, rv.Default)
+				continue
+			}
+			missing = append(missing, rv.Name)
+			continue
+		}
+		message := rv.Name
+		if rv.Description != "" {
+			message = fmt.Sprintf("%s (%s)", rv.Name, rv.Description)
+		}
+		value, err := ask(promptOptions{message: message, def: rv.Default, choices: rv.Choices, hidden: rv.Hidden})
+		if err != nil {
+			return err
+		}
+		if rv.Hidden {
+			r.secrets.add(value)
+		}
+		// keep the value for the next rendering
+		setVar(r.ExtraVariables, rv.Name, value)
+		setVar(vars, rv.Name, value)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required vars: %s (set them with --var)", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// prompt is the template function, options can have default, choices and hidden:
+//
+//	{{ prompt "Environment" (dict "default" "dev" "choices" (list "dev" "prod")) }}
+func (r *Interpreter) prompt(message string, options ...map[string]any) (string, error) {
+	o := promptOptions{message: message}
+	for _, opt := range options {
+		if def, ok := opt["default"]; ok {
+			o.def = fmt.Sprint(def)
+		}
+		if choices, ok := opt["choices"].([]any); ok {
+			for _, c := range choices {
+				o.choices = append(o.choices, fmt.Sprint(c))
+			}
+		}
+		if choices, ok := opt["choices"].([]string); ok {
+			o.choices = choices
+		}
+		o.hidden, _ = opt["hidden"].(bool)
+	}
+	if answer, ok := r.promptAnswers[message]; ok {
+		return answer, nil
+	}
+	if !r.Prompt {
+		return o.def, nil
+	}
+	if !isForegroundTerminal() {
+		if o.def != "" {
+			return o.def, nil
+		}
+		return "", templateFuncError{errors.Wrapf(errNotInteractive, "cannot ask for %s", message)}
+	}
+	answer, err := ask(o)
+	if err != nil {
+		return "", err
+	}
+	if o.hidden {
+		r.secrets.add(answer)
+	}
+	r.promptAnswers[message] = answer
+	return answer, nil
+}
+
+// ask reads the answer from stdin until it is valid, hidden input is not echoed (by stty)
+func ask(o promptOptions) (string, error) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		question := o.message
+		if len(o.choices) > 0 {
+			question += fmt.Sprintf(" [%s]", strings.Join(o.choices, "/"))
+		}
+		if o.def != "" && !o.hidden {
+			question += fmt.Sprintf(" (default %s)", o.def)
+		}
+		fmt.Fprintf(os.Stderr, "%s: ", question)
+		if o.hidden {
+			setEcho(false)
+		}
+		line, err := reader.ReadString('\n')
+		if o.hidden {
+			setEcho(true)
+			fmt.Fprintln(os.Stderr)
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "cannot read %s", o.message)
+		}
+		value := strings.TrimRight(line, "\r\n")
+		if !o.hidden {
+			value = strings.TrimSpace(value)
+		}
+		if value == "" {
+			value = o.def
+		}
+		switch {
+		case value == "":
+			fmt.Fprintln(os.Stderr, "a value is required")
+		case len(o.choices) > 0 && !isAtList(o.choices, value):
+			fmt.Fprintf(os.Stderr, "must be one of %s\n", strings.Join(o.choices, ", "))
+		default:
+			return value, nil
+		}
+	}
+}
+
+func setEcho(on bool) {
+	arg := "-echo"
+	if on {
+		arg = "echo"
+	}
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = os.Stdin
+	cmd.Run()
+}
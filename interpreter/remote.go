@@ -0,0 +1,227 @@
+package interpreter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	LockFileName    = "gomake.lock"
+	includeCacheDir = "includes"
+	cacheSubDir     = "cache"
+	DefaultCacheTTL = time.Hour
+)
+
+// LockFile pins the content of remote includes, it is written next to the gomake file
+type LockFile struct {
+	// sha256 of the content of each included url
+	Includes map[string]string `yaml:"includes,omitempty"`
+	// commit of each ref of git includes like https://host/org/helpers.git?ref=v1.2
+	Refs map[string]string `yaml:"refs,omitempty"`
+}
+
+func isRemotePath(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+func getCachePath(kind, key string) string {
+	return filepath.Join(StateDir, cacheSubDir, kind, hashString(key))
+}
+
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// getRemoteContent returns the content of the url from the cache (if it is not older than CacheTTL) or downloads it.
+// Offline only the cache is used. The content has to match sum (if set) or the hash at gomake.lock.
+func (r *Interpreter) getRemoteContent(url, sum string) (FileContent, error) {
+	cacheFile := getCachePath(includeCacheDir, url)
+	cached, cacheErr := os.ReadFile(cacheFile)
+	fresh := false
+	if info, err := os.Stat(cacheFile); err == nil {
+		fresh = time.Since(info.ModTime()) < r.CacheTTL
+	}
+
+	content := string(cached)
+	downloaded := false
+	switch {
+	case r.Offline:
+		if cacheErr != nil {
+			return FileContent{}, fmt.Errorf("%s is not cached, it can not be downloaded in offline mode: %w", url, os.ErrNotExist)
+		}
+	case cacheErr != nil || !fresh:
+		res, err := download(url)
+		if err != nil {
+			if _, ok := err.(*httpStatusError); ok || cacheErr != nil {
+				return FileContent{}, err
+			}
+			fmt.Fprintf(os.Stderr, "use cached %s: %s\n", url, err)
+			break
+		}
+		content, downloaded = res, true
+	}
+
+	if err := r.verifyContent(url, content, sum); err != nil {
+		return FileContent{}, err
+	}
+	// only verified content is cached
+	if downloaded {
+		if err := os.MkdirAll(filepath.Dir(cacheFile), 0755); err != nil {
+			return FileContent{}, err
+		}
+		if err := os.WriteFile(cacheFile, []byte(content), 0644); err != nil {
+			return FileContent{}, err
+		}
+	}
+	return FileContent{Source: url, Content: content}, nil
+}
+
+func download(url string) (string, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return "", &httpStatusError{url: url, status: resp.Status, code: resp.StatusCode}
+	}
+	b, err := io.ReadAll(resp.Body)
+	return string(b), err
+}
+
+// verifyContent checks the sha256 of content against sum or gomake.lock, unknown urls are added to gomake.lock
+func (r *Interpreter) verifyContent(url, content, sum string) error {
+	if sum != "" {
+		return verifySum(url, content, sum)
+	}
+	actual := hashString(content)
+	lock, err := r.getLockFile()
+	if err != nil {
+		return err
+	}
+	if expected, ok := lock.Includes[url]; ok {
+		if expected != actual {
+			return fmt.Errorf("sha256 of %s is %s but %s is expected by %s, the file was changed or tampered (remove the entry to accept it)", url, actual, expected, r.getLockFilePath())
+		}
+		return nil
+	}
+	lock.Includes[url] = actual
+	return r.saveLockFile()
+}
+
+func verifySum(source, content, sum string) error {
+	if actual := hashString(content); !strings.EqualFold(sum, actual) {
+		return fmt.Errorf("sha256 of %s is %s but %s is expected, the file was changed or tampered", source, actual, sum)
+	}
+	return nil
+}
+
+func (r *Interpreter) getLockFilePath() string {
+	return filepath.Join(filepath.Dir(r.MakeFile), LockFileName)
+}
+
+// getLockFile reads gomake.lock once
+func (r *Interpreter) getLockFile() (*LockFile, error) {
+	if r.lock != nil {
+		return r.lock, nil
+	}
+	lock := &LockFile{}
+	b, err := os.ReadFile(r.getLockFilePath())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(b, lock); err != nil {
+		return nil, fmt.Errorf("%s: %s", r.getLockFilePath(), err)
+	}
+	if lock.Includes == nil {
+		lock.Includes = make(map[string]string)
+	}
+	if lock.Refs == nil {
+		lock.Refs = make(map[string]string)
+	}
+	r.lock = lock
+	return lock, nil
+}
+
+func (r *Interpreter) saveLockFile() error {
+	b, err := yaml.Marshal(r.lock)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.getLockFilePath(), b, 0644)
+}
+
+// UpdateDeps resolves the refs of git includes to their latest commit and downloads urls again,
+// filter limits it to the given repositories and urls. It returns the changed entries of gomake.lock.
+func (r *Interpreter) UpdateDeps(filter []string) ([]string, error) {
+	if r.Offline {
+		return nil, fmt.Errorf("deps update needs network, it can not run in offline mode")
+	}
+	lock, err := r.getLockFile()
+	if err != nil {
+		return nil, err
+	}
+	selected := func(names ...string) bool {
+		if len(filter) == 0 {
+			return true
+		}
+		for _, f := range filter {
+			for _, name := range names {
+				if f == name {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	changes := make([]string, 0)
+	for _, key := range getSortedKeys(lock.Refs) {
+		repo, ref, _ := strings.Cut(key, gitRefQuery)
+		if !selected(key, repo) {
+			continue
+		}
+		commit, err := r.resolveGitRef(getCachePath(gitCacheDir, repo), repo, ref)
+		if err != nil {
+			return nil, err
+		}
+		if old := lock.Refs[key]; old != commit {
+			changes = append(changes, fmt.Sprintf("%s %s: %s -> %s", repo, ref, old, commit))
+			lock.Refs[key] = commit
+		}
+	}
+	for _, url := range getSortedKeys(lock.Includes) {
+		if !selected(url) {
+			continue
+		}
+		content, err := download(url)
+		if err != nil {
+			return nil, err
+		}
+		if old, actual := lock.Includes[url], hashString(content); old != actual {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", url, old, actual))
+			lock.Includes[url] = actual
+		}
+		cacheFile := getCachePath(includeCacheDir, url)
+		if err := os.MkdirAll(filepath.Dir(cacheFile), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(cacheFile, []byte(content), 0644); err != nil {
+			return nil, err
+		}
+	}
+	if len(changes) == 0 {
+		return changes, nil
+	}
+	return changes, r.saveLockFile()
+}
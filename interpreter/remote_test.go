@@ -0,0 +1,25 @@
+package interpreter
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fasibio/gomake/command"
+)
+
+func TestOfflineNotCachedInclude(t *testing.T) {
+	dir := chdirTemp(t)
+	r := NewInterpreter("gomake", "", "sh", false, command.NewCommandHandler("gomake", 0), nil)
+	r.MakeFile = filepath.Join(dir, "gomake.yml")
+	r.Offline = true
+	url := "https://example.invalid/gomake.yml"
+
+	files, err := r.getIncludeFileContents(url, includeFileOptions{optional: true})
+	if err != nil || len(files) != 0 {
+		t.Errorf("optional include got %v, %v, want it skipped", files, err)
+	}
+	if _, err := r.getIncludeFileContents(url, includeFileOptions{}); err == nil || !strings.Contains(err.Error(), "is not cached") {
+		t.Errorf("got %v, want not cached error", err)
+	}
+}
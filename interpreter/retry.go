@@ -0,0 +1,79 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fasibio/gomake/command"
+	"github.com/pkg/errors"
+)
+
+const (
+	RetryBackoffConstant    = "constant"
+	RetryBackoffExponential = "exponential"
+)
+
+type retryPolicy struct {
+	attempts    int
+	delay       time.Duration
+	exponential bool
+}
+
+func getRetryPolicy(retry *command.RetryOperation) (retryPolicy, error) {
+	res := retryPolicy{attempts: 1}
+	if retry == nil {
+		return res, nil
+	}
+	if retry.Attempts > 1 {
+		res.attempts = retry.Attempts
+	}
+	if retry.Delay != "" {
+		delay, err := time.ParseDuration(retry.Delay)
+		if err != nil {
+			return res, errors.Wrapf(err, "invalid retry delay %s", retry.Delay)
+		}
+		res.delay = delay
+	}
+	switch retry.Backoff {
+	case "", RetryBackoffConstant:
+	case RetryBackoffExponential:
+		res.exponential = true
+	default:
+		return res, fmt.Errorf("unknown retry backoff %s only %s and %s are allowed", retry.Backoff, RetryBackoffConstant, RetryBackoffExponential)
+	}
+	return res, nil
+}
+
+// getDelay returns the time to wait before the given attempt (starting with 2)
+func (p retryPolicy) getDelay(attempt int) time.Duration {
+	if !p.exponential {
+		return p.delay
+	}
+	return p.delay * time.Duration(1<<(attempt-2))
+}
+
+// retry calls fn until it succeeds or the attempts of the operation retry policy are used up.
+// Interrupted and cancelled runs are not retried.
+func (r *Interpreter) retry(ctx context.Context, operator StageOperationWrapper, fn func() error) error {
+	policy, err := getRetryPolicy(operator.Command.Retry)
+	if err != nil {
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || errors.Is(err, ErrInterrupted) || err == errCancelled || attempt >= policy.attempts {
+			return err
+		}
+		delay := policy.getDelay(attempt + 1)
+		operator.Write([]byte(fmt.Sprintf("attempt %d/%d failed (%s), retry in %s\n", attempt, policy.attempts, err, delay)))
+		select {
+		case <-time.After(delay):
+		case <-r.processes.interruptedCh:
+			return errors.Wrap(ErrInterrupted, err.Error())
+		case <-ctx.Done():
+			return errCancelled
+		}
+		operator.Write([]byte(fmt.Sprintf("attempt %d/%d\n", attempt+1, policy.attempts)))
+	}
+}
@@ -0,0 +1,209 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fasibio/gomake/command"
+	nearfinder "github.com/fasibio/gomake/nearFinder"
+)
+
+const (
+	runStatusOk      = "ok"
+	runStatusFailed  = "failed"
+	runStatusSkipped = "skipped"
+	// the command was cancelled while running because an other one failed (fail fast)
+	runStatusCancelled = "cancelled"
+	// the command already ran as need of an other command
+	runStatusDone = "done"
+)
+
+type runResult struct {
+	name     string
+	status   string
+	duration time.Duration
+}
+
+// runRequest is one command given at gomake run with its params
+type runRequest struct {
+	name      string
+	paramArgs []string
+}
+
+// splitRunArgs splits the args after the first command into the following commands and their params.
+// An arg which is not the value of a param is the name of the next command.
+func splitRunArgs(first string, args []string, makefile command.MakeStruct) []runRequest {
+	requests := []runRequest{{name: first}}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !isParamArg(arg) {
+			requests = append(requests, runRequest{name: arg})
+			continue
+		}
+		current := &requests[len(requests)-1]
+		current.paramArgs = append(current.paramArgs, arg)
+		if strings.Contains(arg, "=") || i+1 == len(args) || isParamArg(args[i+1]) {
+			continue
+		}
+		if p, ok := findParam(makefile[current.name].Params, strings.TrimLeft(arg, "-")); ok && p.Type == ParamTypeBool && !isBoolString(args[i+1]) {
+			continue
+		}
+		i++
+		current.paramArgs = append(current.paramArgs, args[i])
+	}
+	return requests
+}
+
+// prepareRun returns the command of the request and all of its needs (in execution order) rendered with its params
+func (r *Interpreter) prepareRun(request runRequest, makefile command.MakeStruct, variables VarsDocument) ([]StageOperationWrapper, VarsDocument, error) {
+	if _, ok := makefile[request.name]; !ok {
+		return nil, VarsDocument{}, fmt.Errorf("command %s not exist at makefile, did you mean \n%s", request.name, nearfinder.ClosestMatch(request.name, nearfinder.GetKeysOfMap(makefile), 2))
+	}
+	if len(makefile[request.name].Params) > 0 || len(request.paramArgs) > 0 {
+		defer func() {
+			r.templateParams = nil
+		}()
+		var err error
+		_, variables, makefile, err = r.renderWithParams(request.name, request.paramArgs, makefile)
+		if err != nil {
+			return nil, VarsDocument{}, err
+		}
+	}
+
+	order, err := getNeedsOrder(request.name, makefile)
+	if err != nil {
+		return nil, VarsDocument{}, err
+	}
+	commands := make([]StageOperationWrapper, 0)
+	for _, name := range order {
+		tmpc, err := r.cmdHandler.GetExecutedCommandMakeScript(name, makefile)
+		if err != nil {
+			return nil, VarsDocument{}, err
+		}
+		commands = append(commands, StageOperationWrapper{Name: name, Command: tmpc[name]})
+	}
+	return commands, variables, nil
+}
+
+// runCommand executes the needs and at last the command itself (the last entry of commands)
+func (r *Interpreter) runCommand(commands []StageOperationWrapper) error {
+	if needs := commands[:len(commands)-1]; len(needs) > 0 {
+		if err := r.runNeeds(needs); err != nil {
+			return err
+		}
+	}
+	return r.runOperation(context.Background(), commands[len(commands)-1], true)
+}
+
+// runSequential executes the prepared commands one after another and stops at the first failed command.
+// Commands are only executed once, also if they are needed by several commands or are a need of an other one.
+func (r *Interpreter) runSequential(prepared [][]StageOperationWrapper) error {
+	results := make([]runResult, 0)
+	done := make(map[string]bool)
+	var runErr error
+	for _, commands := range prepared {
+		name := commands[len(commands)-1].Name
+		if runErr != nil {
+			results = append(results, runResult{name: name, status: runStatusSkipped})
+			continue
+		}
+		if done[name] {
+			results = append(results, runResult{name: name, status: runStatusDone})
+			continue
+		}
+		todo := make([]StageOperationWrapper, 0)
+		for _, c := range commands[:len(commands)-1] {
+			if !done[c.Name] {
+				todo = append(todo, c)
+			}
+		}
+		todo = append(todo, commands[len(commands)-1])
+		start := time.Now()
+		err := r.runCommand(todo)
+		result := runResult{name: name, status: runStatusOk, duration: time.Since(start)}
+		if err != nil {
+			result.status = runStatusFailed
+			runErr = fmt.Errorf("%s failed: %s", name, err)
+		} else {
+			for _, c := range todo {
+				done[c.Name] = true
+			}
+		}
+		results = append(results, result)
+	}
+	printSummary("Run summary:", results)
+	return runErr
+}
+
+// runParallelCommands executes the needs of all prepared commands (each once) and then the commands in parallel like srun.
+// A command which is a need of an other one only runs as need.
+func (r *Interpreter) runParallelCommands(prepared [][]StageOperationWrapper) error {
+	needs := make([]StageOperationWrapper, 0)
+	seen := make(map[string]bool)
+	for _, commands := range prepared {
+		for _, c := range commands[:len(commands)-1] {
+			if !seen[c.Name] {
+				seen[c.Name] = true
+				needs = append(needs, c)
+			}
+		}
+	}
+	// names of all requested commands in their order, targets are the ones which are not already a need
+	names := make([]string, 0)
+	targets := make([]StageOperationWrapper, 0)
+	for _, commands := range prepared {
+		c := commands[len(commands)-1]
+		if isAtList(names, c.Name) {
+			continue
+		}
+		names = append(names, c.Name)
+		if !seen[c.Name] {
+			targets = append(targets, c)
+		}
+	}
+	if len(needs) > 0 {
+		if err := r.runNeeds(needs); err != nil {
+			results := make([]runResult, 0)
+			for _, name := range names {
+				results = append(results, runResult{name: name, status: runStatusSkipped})
+			}
+			printSummary("Run summary:", results)
+			return err
+		}
+	}
+	targetResults, errMsgs := r.runParallel(targets, StageConfig{MaxParallel: r.MaxParallel, FailFast: r.FailFast})
+	results := make([]runResult, 0)
+	for _, name := range names {
+		if seen[name] {
+			results = append(results, runResult{name: name, status: runStatusDone})
+			continue
+		}
+		for _, res := range targetResults {
+			if res.name == name {
+				results = append(results, res)
+			}
+		}
+	}
+	printSummary("Run summary:", results)
+	if len(errMsgs) > 0 {
+		return fmt.Errorf("run failed:\n%s", strings.Join(errMsgs, "\n"))
+	}
+	return nil
+}
+
+func printSummary(title string, results []runResult) {
+	fmt.Printf("\n%s\n", title)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, res := range results {
+		duration := "-"
+		if res.status != runStatusSkipped && res.status != runStatusDone {
+			duration = res.duration.Round(time.Millisecond).String()
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", res.name, strings.ToUpper(res.status), duration)
+	}
+	w.Flush()
+}
@@ -0,0 +1,188 @@
+package interpreter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	secretMask = "***"
+	// shorter values (like 80 or true) would mask unrelated output
+	minSecretLen = 6
+)
+
+// secretRegistry knows all secret values to replace them at every output
+type secretRegistry struct {
+	mu     sync.Mutex
+	values map[string]bool
+	// the warning about too short secrets is only shown once
+	warned bool
+}
+
+func newSecretRegistry() *secretRegistry {
+	return &secretRegistry{values: make(map[string]bool)}
+}
+
+// add registers value, for multiline values also each line. Values (and lines) shorter than minSecretLen are not masked.
+func (s *secretRegistry) add(value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if trimmed := strings.TrimSpace(value); trimmed != "" && len(trimmed) < minSecretLen && !s.warned {
+		s.warned = true
+		fmt.Fprintf(os.Stderr, "Warning: secrets with less than %d characters are not masked\n", minSecretLen)
+	}
+	candidates := append([]string{value, strings.TrimSpace(value)}, strings.Split(value, "\n")...)
+	for _, v := range candidates {
+		if v = strings.TrimRight(v, "\r"); len(strings.TrimSpace(v)) >= minSecretLen {
+			s.values[v] = true
+		}
+	}
+}
+
+// addValue registers all values of a variable (nested vars and lists too)
+func (s *secretRegistry) addValue(value any) {
+	if m, ok := toVarsMap(value); ok {
+		for _, v := range m {
+			s.addValue(v)
+		}
+		return
+	}
+	if l, ok := value.([]any); ok {
+		for _, v := range l {
+			s.addValue(v)
+		}
+		return
+	}
+	if value != nil {
+		s.add(fmt.Sprint(value))
+	}
+}
+
+func (s *secretRegistry) isEmpty() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.values) == 0
+}
+
+// getValues returns all secrets, longer secrets first
+func (s *secretRegistry) getValues() []string {
+	s.mu.Lock()
+	values := make([]string, 0, len(s.values))
+	for v := range s.values {
+		values = append(values, v)
+	}
+	s.mu.Unlock()
+	sort.Slice(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+	return values
+}
+
+// mask replaces all secrets at text with ***, longer secrets first
+func (s *secretRegistry) mask(text string) string {
+	for _, v := range s.getValues() {
+		text = strings.ReplaceAll(text, v, secretMask)
+	}
+	return text
+}
+
+// getMaskableEnd returns the end of the part of text which can be masked now.
+// The rest could be the start of a secret which is completed by the next output.
+func (s *secretRegistry) getMaskableEnd(text string) int {
+	values := s.getValues()
+	end := len(text)
+	if len(values) == 0 {
+		return end
+	}
+	// the first end of text which is the start of a secret (values[0] is the longest one)
+	start := len(text) - len(values[0]) + 1
+	if start < 0 {
+		start = 0
+	}
+	for i := start; i < len(text) && end == len(text); i++ {
+		for _, v := range values {
+			if len(text)-i < len(v) && strings.HasPrefix(v, text[i:]) {
+				end = i
+				break
+			}
+		}
+	}
+	// a secret must not be split at end
+	for changed := true; changed; {
+		changed = false
+		for _, v := range values {
+			for i := end - len(v) + 1; i < end; i++ {
+				if i >= 0 && strings.HasPrefix(text[i:], v) {
+					end, changed = i, true
+					break
+				}
+			}
+		}
+	}
+	return end
+}
+
+// maskValue returns a copy of value with all secrets of strings replaced
+func (s *secretRegistry) maskValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		return s.mask(v)
+	case []any:
+		res := make([]any, 0, len(v))
+		for _, e := range v {
+			res = append(res, s.maskValue(e))
+		}
+		return res
+	case map[any]any:
+		res := make(map[any]any)
+		for k, e := range v {
+			res[k] = s.maskValue(e)
+		}
+		return res
+	case map[string]any:
+		res := make(map[string]any)
+		for k, e := range v {
+			res[k] = s.maskValue(e)
+		}
+		return res
+	}
+	return value
+}
+
+// maskWriter replaces secrets before writing to w.
+// The end of a write which could be the start of a secret is kept until the next write (or Flush),
+// so secrets split over several writes are found too.
+type maskWriter struct {
+	w       io.Writer
+	secrets *secretRegistry
+	pending string
+}
+
+func (m *maskWriter) Write(data []byte) (int, error) {
+	m.pending += string(data)
+	end := m.secrets.getMaskableEnd(m.pending)
+	if end == 0 {
+		return len(data), nil
+	}
+	text := m.pending[:end]
+	m.pending = m.pending[end:]
+	if _, err := m.w.Write([]byte(m.secrets.mask(text))); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// Flush writes the kept end of the output
+func (m *maskWriter) Flush() error {
+	if m.pending == "" {
+		return nil
+	}
+	text := m.pending
+	m.pending = ""
+	_, err := m.w.Write([]byte(m.secrets.mask(text)))
+	return err
+}
@@ -0,0 +1,54 @@
+package interpreter
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMaskWriterSplitSecret(t *testing.T) {
+	secrets := newSecretRegistry()
+	secrets.add("abc123")
+	secrets.add("secret-token")
+	output := "login abc123 with secret-token and abc12 done"
+	want := "login *** with *** and abc12 done"
+	// every split of the output into two writes
+	for i := 0; i <= len(output); i++ {
+		var b bytes.Buffer
+		w := &maskWriter{w: &b, secrets: secrets}
+		w.Write([]byte(output[:i]))
+		w.Write([]byte(output[i:]))
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != want {
+			t.Errorf("split at %d: got %q, want %q", i, b.String(), want)
+		}
+	}
+}
+
+func TestMaskWriterByteByByte(t *testing.T) {
+	secrets := newSecretRegistry()
+	secrets.add("abc123")
+	var b bytes.Buffer
+	w := &maskWriter{w: &b, secrets: secrets}
+	for _, c := range []byte("x abc123 abc1") {
+		w.Write([]byte{c})
+	}
+	if got := b.String(); got != "x *** " {
+		t.Errorf("got %q before flush, want the possible start of a secret kept", got)
+	}
+	w.Flush()
+	if got := b.String(); got != "x *** abc1" {
+		t.Errorf("got %q, want %q", got, "x *** abc1")
+	}
+}
+
+func TestShortSecretsAreNotMasked(t *testing.T) {
+	secrets := newSecretRegistry()
+	secrets.add("80")
+	secrets.add("true")
+	secrets.add("line1-long\n}\n")
+	if got := secrets.mask("port 80 is true\nline1-long {}"); got != "port 80 is true\n*** {}" {
+		t.Errorf("got %q", got)
+	}
+}
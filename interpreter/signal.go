@@ -0,0 +1,134 @@
+package interpreter
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInterrupted = errors.New("interrupted")
+	errNotStarted  = errors.New("not started because gomake was interrupted")
+	errSkipped     = errors.New("not started because an other command failed (fail fast)")
+	errCancelled   = errors.New("cancelled because an other command failed (fail fast)")
+)
+
+// isNotStarted is true for scripts skipped because gomake was interrupted or an other command failed with fail fast
+func isNotStarted(err error) bool {
+	return err == errNotStarted || err == errSkipped
+}
+
+// isFailFast is true for scripts skipped or cancelled because an other command failed with fail fast, they are no failure of their own
+func isFailFast(err error) bool {
+	return err == errSkipped || err == errCancelled
+}
+
+// processRegistry knows all running child processes to forward signals to them
+type processRegistry struct {
+	mu          sync.Mutex
+	running     map[*exec.Cmd]bool
+	interrupted bool
+	// closed as soon as gomake is interrupted
+	interruptedCh chan struct{}
+}
+
+func newProcessRegistry() *processRegistry {
+	return &processRegistry{running: make(map[*exec.Cmd]bool), interruptedCh: make(chan struct{})}
+}
+
+func (p *processRegistry) add(cmd *exec.Cmd) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running[cmd] = false
+}
+
+// remove returns true if the process got a forwarded signal
+func (p *processRegistry) remove(cmd *exec.Cmd) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	signaled := p.running[cmd]
+	delete(p.running, cmd)
+	return signaled
+}
+
+func (p *processRegistry) isInterrupted() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.interrupted
+}
+
+func (p *processRegistry) setInterrupted() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.markInterrupted()
+}
+
+// markInterrupted needs the lock to be held
+func (p *processRegistry) markInterrupted() {
+	if !p.interrupted {
+		p.interrupted = true
+		close(p.interruptedCh)
+	}
+}
+
+// forward sends sig to all running process groups and returns them
+func (p *processRegistry) forward(sig os.Signal) []*exec.Cmd {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.markInterrupted()
+	res := make([]*exec.Cmd, 0)
+	for cmd := range p.running {
+		p.running[cmd] = true
+		signalProcessGroup(cmd, sig)
+		res = append(res, cmd)
+	}
+	return res
+}
+
+// kill terminates all given process groups which are still running
+func (p *processRegistry) kill(cmds []*exec.Cmd) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, cmd := range cmds {
+		if _, ok := p.running[cmd]; ok {
+			killProcessGroup(cmd)
+		}
+	}
+}
+
+// handleSignals forwards SIGINT and SIGTERM to all running scripts and kills them after the grace period.
+// A second signal kills them immediately. The returned func stops the handling.
+func (r *Interpreter) handleSignals() func() {
+	sigs := make(chan os.Signal, 1)
+	stop := make(chan struct{})
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case sig := <-sigs:
+				if r.processes.isInterrupted() {
+					fmt.Printf("Got %s again, kill all running scripts\n", sig)
+					r.processes.kill(r.processes.forward(sig))
+					continue
+				}
+				fmt.Printf("Got %s, forward it to all running scripts (grace period %s)\n", sig, r.GracePeriod)
+				cmds := r.processes.forward(sig)
+				time.AfterFunc(r.GracePeriod, func() {
+					r.processes.kill(cmds)
+				})
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sigs)
+		close(stop)
+	}
+}
@@ -0,0 +1,197 @@
+package interpreter
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/pkg/errors"
+)
+
+// sourceMarker encloses the source of a line (like \x1f12@gomake.yml\x1f) while a template is rendered
+const sourceMarker = "\x1f"
+
+var (
+	sourceMarkerRegex = regexp.MustCompile(sourceMarker + `(\d+)@([^` + sourceMarker + `]*)` + sourceMarker)
+	quotedTokenRegex  = regexp.MustCompile(`"([^"]+)"`)
+)
+
+// sourceLine is the file and line a line of a rendered template comes from
+type sourceLine struct {
+	file string
+	line int
+}
+
+func (s sourceLine) marker() string {
+	return fmt.Sprintf("%s%d@%s%s", sourceMarker, s.line, s.file, sourceMarker)
+}
+
+// sourceMap is the source of each line of a rendered template
+type sourceMap []sourceLine
+
+// locate returns file and line of the source of line of the rendered template
+func (m sourceMap) locate(line int) (string, int) {
+	if len(m) == 0 {
+		return "", line
+	}
+	if line < 1 {
+		line = 1
+	}
+	if line > len(m) {
+		line = len(m)
+	}
+	return m[line-1].file, m[line-1].line
+}
+
+func (s templateSource) locate(line int) (string, int) {
+	return s.file, s.offset + line
+}
+
+// markSourceLines adds the source line behind each newline of the text of the parsed templates.
+// The output of actions has no markers, so their lines get the line of the action.
+func markSourceLines(t *template.Template, tmpl string, source templateSource) {
+	newlines := make([]int, 0)
+	for i, c := range tmpl {
+		if c == '\n' {
+			newlines = append(newlines, i)
+		}
+	}
+	lineAt := func(pos int) sourceLine {
+		return sourceLine{file: source.file, line: source.offset + sort.SearchInts(newlines, pos) + 1}
+	}
+	var mark func(node parse.Node)
+	mark = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Nodes {
+				mark(c)
+			}
+		case *parse.IfNode:
+			mark(n.List)
+			mark(n.ElseList)
+		case *parse.RangeNode:
+			mark(n.List)
+			mark(n.ElseList)
+		case *parse.WithNode:
+			mark(n.List)
+			mark(n.ElseList)
+		case *parse.TextNode:
+			var b bytes.Buffer
+			if n.Pos == 0 {
+				b.WriteString(lineAt(0).marker())
+			}
+			for i, c := range n.Text {
+				b.WriteByte(c)
+				if c == '\n' {
+					b.WriteString(lineAt(int(n.Pos) + i + 1).marker())
+				}
+			}
+			n.Text = b.Bytes()
+		}
+	}
+	for _, tt := range t.Templates() {
+		if tt.Tree != nil {
+			mark(tt.Tree.Root)
+		}
+	}
+}
+
+// stripSourceMarkers removes the markers from the rendered template and returns the source of each line.
+// The last marker of a line wins (like the first line of an includeFile after indention).
+func stripSourceMarkers(rendered []byte, source templateSource) ([]byte, sourceMap) {
+	lines := strings.Split(string(rendered), "\n")
+	m := make(sourceMap, len(lines))
+	current := sourceLine{file: source.file, line: source.offset + 1}
+	for i, line := range lines {
+		if found := sourceMarkerRegex.FindAllStringSubmatch(line, -1); found != nil {
+			last := found[len(found)-1]
+			n, _ := strconv.Atoi(last[1])
+			current = sourceLine{file: last[2], line: n}
+			lines[i] = sourceMarkerRegex.ReplaceAllString(line, "")
+		}
+		m[i] = current
+	}
+	return []byte(strings.Join(lines, "\n")), m
+}
+
+// addSourceMarkers adds the source of each line again, so the template including content keeps them
+func addSourceMarkers(content []byte, m sourceMap) []byte {
+	lines := strings.Split(string(content), "\n")
+	for i := range lines {
+		if i < len(m) {
+			lines[i] = m[i].marker() + lines[i]
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// setText sets the line of the error from content of the file, the column of quoted tokens (like function "foo" not defined) is searched at it
+func (e *TemplateError) setText(content string) {
+	lines := strings.Split(content, "\n")
+	if e.Line < 1 || e.Line > len(lines) {
+		return
+	}
+	e.Text = strings.TrimRight(lines[e.Line-1], "\r")
+	if e.Column > 0 {
+		return
+	}
+	for _, token := range quotedTokenRegex.FindAllStringSubmatch(e.Message, -1) {
+		if i := strings.Index(e.Text, token[1]); i >= 0 {
+			e.Column = i + 1
+			return
+		}
+	}
+}
+
+// Snippet is the line of the error with a caret below the column (below the first character if it is unknown)
+func (e *TemplateError) Snippet() string {
+	prefix := fmt.Sprintf("%5d | ", e.Line)
+	column := e.Column
+	if column < 1 {
+		column = len(e.Text) - len(strings.TrimLeft(e.Text, " \t")) + 1
+	}
+	indent := []byte(e.Text)
+	if column-1 < len(indent) {
+		indent = indent[:column-1]
+	}
+	for i, c := range indent {
+		// tabs stay tabs, so the caret has the same position
+		if c != '\t' {
+			indent[i] = ' '
+		}
+	}
+	return fmt.Sprintf("%s%s\n%s| %s^", prefix, e.Text, strings.Repeat(" ", len(prefix)-2), indent)
+}
+
+// addSnippet adds the line of its file to template errors (and to each of undefined vars)
+func (r *Interpreter) addSnippet(err error) error {
+	var undefined UndefinedVarsError
+	if errors.As(err, &undefined) {
+		for _, e := range undefined {
+			r.setSourceText(e)
+		}
+		return err
+	}
+	var templateErr *TemplateError
+	if errors.As(err, &templateErr) {
+		r.setSourceText(templateErr)
+	}
+	return err
+}
+
+func (r *Interpreter) setSourceText(e *TemplateError) {
+	if e.Text != "" || e.nested {
+		return
+	}
+	if content, ok := r.sourceTexts[e.File]; ok {
+		e.setText(content)
+	}
+}
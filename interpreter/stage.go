@@ -0,0 +1,130 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fasibio/gomake/command"
+	nearfinder "github.com/fasibio/gomake/nearFinder"
+)
+
+// StageConfig can be set per stage at the vars document
+//
+//	stage_config:
+//	  build:
+//	    max_parallel: 2
+//	    fail_fast: true
+type StageConfig struct {
+	// 0 is unlimited
+	MaxParallel int  `yaml:"max_parallel,omitempty"`
+	FailFast    bool `yaml:"fail_fast,omitempty"`
+}
+
+// getStageConfig returns the stage_config of the stage overwritten by the cli flags
+func (r *Interpreter) getStageConfig(stage string, variables VarsDocument) StageConfig {
+	config := variables.StageConfig[stage]
+	if r.MaxParallel > 0 {
+		config.MaxParallel = r.MaxParallel
+	}
+	config.FailFast = config.FailFast || r.FailFast
+	return config
+}
+
+// getStageCommands returns the executed make scripts of all commands of the stage
+func (r *Interpreter) getStageCommands(stage string, stagesMap map[string][]StageOperationWrapper, makefile command.MakeStruct) ([]StageOperationWrapper, error) {
+	if _, ok := stagesMap[stage]; !ok {
+		return nil, fmt.Errorf("no command with stage %s found at makefile, did you mean \n%s", stage, nearfinder.ClosestMatch(stage, nearfinder.GetKeysOfMap(stagesMap), 2))
+	}
+	commands := make([]StageOperationWrapper, 0)
+	for _, c := range stagesMap[stage] {
+		tmpc, err := r.cmdHandler.GetExecutedCommandMakeScript(c.Name, makefile)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, StageOperationWrapper{Name: c.Name, Command: tmpc[c.Name]})
+	}
+	return commands, nil
+}
+
+// runStage executes all commands in parallel (but not more than config.MaxParallel at the same time).
+// With config.FailFast the first failing command cancels all others.
+// The on_failure (or on_interrupt) scripts are executed after all commands are done.
+func (r *Interpreter) runStage(stage string, commands []StageOperationWrapper, config StageConfig) error {
+	if _, errMsgs := r.runParallel(commands, config); len(errMsgs) > 0 {
+		return fmt.Errorf("stage %s failed:\n%s", stage, strings.Join(errMsgs, "\n"))
+	}
+	return nil
+}
+
+// runParallel is the implementation of runStage, it returns the result of each command and all error messages
+func (r *Interpreter) runParallel(commands []StageOperationWrapper, config StageConfig) ([]runResult, []string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var slots chan struct{}
+	if config.MaxParallel > 0 {
+		slots = make(chan struct{}, config.MaxParallel)
+	}
+
+	w := sync.WaitGroup{}
+	mu := sync.Mutex{}
+	errList := make([]StageOperationWrapperError, 0)
+	results := make([]runResult, len(commands))
+	for i, c := range commands {
+		acquired := false
+		if slots != nil {
+			select {
+			case slots <- struct{}{}:
+				acquired = true
+			case <-ctx.Done():
+			}
+		}
+		w.Add(1)
+		go func(i int, operator StageOperationWrapper) {
+			defer w.Done()
+			if acquired {
+				defer func() { <-slots }()
+			}
+			start := time.Now()
+			err := r.runScript(ctx, operator, false)
+			results[i] = runResult{name: operator.Name, status: runStatusOk, duration: time.Since(start)}
+			if err == nil {
+				return
+			}
+			results[i].status = runStatusFailed
+			if isNotStarted(err) {
+				results[i].status = runStatusSkipped
+			} else if err == errCancelled {
+				results[i].status = runStatusCancelled
+			}
+			if config.FailFast && !isNotStarted(err) && !isFailFast(err) && ctx.Err() == nil {
+				operator.Write([]byte("failed, cancel all other running commands (fail fast)\n"))
+				cancel()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			errList = append(errList, StageOperationWrapperError{
+				error:                 err,
+				StageOperationWrapper: operator,
+			})
+		}(i, c)
+	}
+	w.Wait()
+
+	errMsgs := make([]string, 0)
+	for _, e := range errList {
+		if !isFailFast(e.error) {
+			// commands skipped or cancelled by fail fast are no failure of their own
+			errMsgs = append(errMsgs, fmt.Sprintf("%s: %s", e.Name, e.error))
+		}
+		if isNotStarted(e.error) {
+			continue
+		}
+		if err := r.runCleanup(e.StageOperationWrapper, false, e.error); err != nil {
+			errMsgs = append(errMsgs, fmt.Sprintf("%s: cleanup failed: %s", e.Name, err))
+		}
+	}
+	return results, errMsgs
+}
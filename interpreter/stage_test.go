@@ -0,0 +1,37 @@
+package interpreter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/fasibio/gomake/command"
+)
+
+func TestRunParallelFailFast(t *testing.T) {
+	failures := filepath.Join(t.TempDir(), "failures")
+	onFailure := []string{"echo $0 >> " + failures}
+	commands := []StageOperationWrapper{
+		{Name: "slow", Command: command.Operation{Script: []string{"sleep 3"}, On_Failure: onFailure}},
+		{Name: "fail", Command: command.Operation{Script: []string{"sleep 0.2", "exit 2"}}},
+		{Name: "later", Command: command.Operation{Script: []string{"echo later"}, On_Failure: onFailure}},
+	}
+	r := NewInterpreter("gomake", "", "sh", false, command.NewCommandHandler("gomake", 0), nil)
+	results, errMsgs := r.runParallel(commands, StageConfig{MaxParallel: 2, FailFast: true})
+
+	statuses := make(map[string]string)
+	for _, res := range results {
+		statuses[res.name] = res.status
+	}
+	want := map[string]string{"slow": runStatusCancelled, "fail": runStatusFailed, "later": runStatusSkipped}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("got statuses %v, want %v", statuses, want)
+	}
+	if !reflect.DeepEqual(errMsgs, []string{"fail: exit status 2"}) {
+		t.Errorf("got errors %q, want only the failed command", errMsgs)
+	}
+	if _, err := os.Stat(failures); err == nil {
+		t.Errorf("on_failure executed for a cancelled or skipped command")
+	}
+}
@@ -0,0 +1,238 @@
+package interpreter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	nearfinder "github.com/fasibio/gomake/nearFinder"
+	"github.com/pkg/errors"
+)
+
+var (
+	templateErrorRegex  = regexp.MustCompile(`(?s)^template: [^:]+:(\d+):(?:(\d+):)? ?(?:executing "[^"]*" at <(.*?)>: )?(.*)$`)
+	errorLocationRegex  = regexp.MustCompile(`^[^:]+:(\d+):(\d+)`)
+	undefinedVarsHeader = "undefined variables:"
+)
+
+// templateSource is the file of a template, offset is added to the lines of the template to get the line at the file
+type templateSource struct {
+	file   string
+	offset int
+}
+
+// TemplateError is an error of a template at a line of a gomake file
+type TemplateError struct {
+	File string
+	Line int
+	// 0 if unknown
+	Column  int
+	Message string
+	// the line at the file, shown with a caret below the column
+	Text string
+	// the message is the error of an included file which has its own snippet
+	nested bool
+}
+
+func (e *TemplateError) Error() string {
+	msg := fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+	if e.Text == "" {
+		return msg
+	}
+	return msg + "\n" + e.Snippet()
+}
+
+// UndefinedVarsError lists all variables referenced by a template but not defined (only at strict mode)
+type UndefinedVarsError []*TemplateError
+
+func (e UndefinedVarsError) Error() string {
+	lines := []string{undefinedVarsHeader}
+	for _, err := range e {
+		// the snippet is indented like its error
+		lines = append(lines, "  "+strings.ReplaceAll(err.Error(), "\n", "\n  "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// toTemplateError converts errors of text/template (like template: gomake:4:12: ...) to errors with file and line
+func toTemplateError(err error, source templateSource) error {
+	m := templateErrorRegex.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+	line, _ := strconv.Atoi(m[1])
+	res := &TemplateError{File: source.file, Line: line + source.offset, Message: m[4]}
+	if m[2] != "" {
+		// text/template counts from 0
+		column, _ := strconv.Atoi(m[2])
+		res.Column = column + 1
+	}
+	var funcErr templateFuncError
+	var included *TemplateError
+	if errors.As(err, &funcErr) {
+		// the error of the func has all details
+		res.Message = funcErr.Error()
+		res.nested = errors.As(funcErr.error, &included)
+	} else if m[3] != "" {
+		res.Message = fmt.Sprintf("%s: %s", m[3], res.Message)
+	}
+	return res
+}
+
+// templateRef is a variable used at a template like .Vars.version or $.Env.HOME
+type templateRef struct {
+	path []string
+	node parse.Node
+}
+
+// collectTemplateRefs returns all variables starting at the root data and the names of the called functions.
+// Inside range and with . is not the root data, so only $ is used there.
+func collectTemplateRefs(node parse.Node, dotIsRoot bool) ([]templateRef, map[string]bool) {
+	res := make([]templateRef, 0)
+	funcs := make(map[string]bool)
+	var walk func(node parse.Node, dotIsRoot bool)
+	walk = func(node parse.Node, dotIsRoot bool) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Nodes {
+				walk(c, dotIsRoot)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, dotIsRoot)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Cmds {
+				walk(c, dotIsRoot)
+			}
+		case *parse.CommandNode:
+			for _, a := range n.Args {
+				walk(a, dotIsRoot)
+			}
+		case *parse.IdentifierNode:
+			funcs[n.Ident] = true
+		case *parse.FieldNode:
+			if dotIsRoot {
+				res = append(res, templateRef{path: n.Ident, node: n})
+			}
+		case *parse.VariableNode:
+			if len(n.Ident) > 1 && n.Ident[0] == "$" {
+				res = append(res, templateRef{path: n.Ident[1:], node: n})
+			}
+		case *parse.IfNode:
+			walk(n.Pipe, dotIsRoot)
+			walk(n.List, dotIsRoot)
+			walk(n.ElseList, dotIsRoot)
+		case *parse.RangeNode:
+			walk(n.Pipe, dotIsRoot)
+			walk(n.List, false)
+			walk(n.ElseList, dotIsRoot)
+		case *parse.WithNode:
+			walk(n.Pipe, dotIsRoot)
+			walk(n.List, false)
+			walk(n.ElseList, dotIsRoot)
+		case *parse.TemplateNode:
+			walk(n.Pipe, dotIsRoot)
+		}
+	}
+	walk(node, dotIsRoot)
+	return res, funcs
+}
+
+// callsTemplateFunc reports if the template calls the function name
+func callsTemplateFunc(t *template.Template, name string) bool {
+	_, funcs := collectTemplateRefs(t.Tree.Root, true)
+	return funcs[name]
+}
+
+// checkTemplateRefs returns an error with all undefined variables of vars and env.
+// Params and args are optional, so missing ones are added as nil to data (missingkey=error would fail otherwise).
+func checkTemplateRefs(t *template.Template, data *TemplateData, source templateSource) error {
+	params := copyVars(data.Params)
+	args := copyVars(data.Args)
+	undefined := make(UndefinedVarsError, 0)
+	seen := make(map[string]bool)
+	refs, _ := collectTemplateRefs(t.Tree.Root, true)
+	for _, ref := range refs {
+		name := "." + strings.Join(ref.path, ".")
+		var missing string
+		switch ref.path[0] {
+		case "Vars":
+			missing = getMissingVar(data.Vars, ref.path[1:])
+		case "Env":
+			if len(ref.path) > 1 {
+				if _, ok := data.Env[ref.path[1]]; !ok {
+					missing = fmt.Sprintf(".Env.%s is not set", ref.path[1])
+				}
+			}
+		case "Colors":
+			if len(ref.path) > 1 {
+				if _, ok := data.Colors[ref.path[1]]; !ok {
+					missing = fmt.Sprintf("unknown color %s%s", ref.path[1], didYouMean(ref.path[1], nearfinder.GetKeysOfMap(data.Colors)))
+				}
+			}
+		case "Params":
+			if len(ref.path) > 1 {
+				if _, ok := params[ref.path[1]]; !ok {
+					params[ref.path[1]] = nil
+				}
+			}
+		case "Args":
+			if len(ref.path) > 1 {
+				if _, ok := args[ref.path[1]]; !ok {
+					args[ref.path[1]] = nil
+				}
+			}
+		default:
+			missing = fmt.Sprintf("%s is unknown, use .Vars, .Env, .Params, .Args or .Colors", name)
+		}
+		if missing == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		location, _ := t.Tree.ErrorContext(ref.node)
+		line, column := 0, 0
+		if m := errorLocationRegex.FindStringSubmatch(location); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			// text/template counts from 0
+			column, _ = strconv.Atoi(m[2])
+			column++
+		}
+		undefined = append(undefined, &TemplateError{File: source.file, Line: line + source.offset, Column: column, Message: missing})
+	}
+	data.Params = params
+	data.Args = args
+	if len(undefined) > 0 {
+		sort.SliceStable(undefined, func(i, j int) bool {
+			return undefined[i].Line < undefined[j].Line
+		})
+		return undefined
+	}
+	return nil
+}
+
+// getMissingVar returns a message if the var with the path is not defined
+func getMissingVar(vars map[string]any, path []string) string {
+	current := vars
+	for i, k := range path {
+		v, ok := current[k]
+		if !ok {
+			name := ".Vars." + strings.Join(path[:i+1], ".")
+			return fmt.Sprintf("%s is not defined%s", name, didYouMean(k, nearfinder.GetKeysOfMap(current)))
+		}
+		next, ok := toVarsMap(v)
+		if !ok {
+			return ""
+		}
+		current = next
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package interpreter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fasibio/gomake/command"
+)
+
+func TestUndefinedVarsHaveSnippets(t *testing.T) {
+	file := "vars:\n  version: 1\n---\nshow:\n  script:\n    - echo {{.Vars.verison}}\n    - echo {{.Env.GOMAKE_TEST_UNSET}}\n"
+	r := NewInterpreter("gomake", "", "sh", false, command.NewCommandHandler("gomake", 0), []byte(file))
+	r.MakeFile = "gomake.yml"
+	r.Strict = true
+
+	_, _, err := r.GetExecuteTemplate(file, make(map[string]any))
+	undefined, ok := err.(UndefinedVarsError)
+	if !ok || len(undefined) != 2 {
+		t.Fatalf("got %v, want 2 undefined variables", err)
+	}
+	for i, want := range []string{"    - echo {{.Vars.verison}}", "    - echo {{.Env.GOMAKE_TEST_UNSET}}"} {
+		if undefined[i].Text != want {
+			t.Errorf("got text %q, want %q", undefined[i].Text, want)
+		}
+	}
+	if !strings.Contains(err.Error(), "\n        |                   ^") {
+		t.Errorf("caret not below .Vars.verison:\n%s", err)
+	}
+}
+
+func TestStrictVarsOfIncludedFile(t *testing.T) {
+	helper := filepath.Join(t.TempDir(), "helper.yml")
+	if err := os.WriteFile(helper, []byte("vars:\n  helperVar: x\n---\nhelper:\n  script:\n    - echo helper\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		usage string
+		want  []string
+	}{
+		{name: "defined at included file", usage: "{{.Vars.helperVar}}"},
+		{
+			name:  "undefined",
+			usage: "{{.Vars.helperVr}} {{.Env.GOMAKE_TEST_UNSET}}",
+			want:  []string{".Vars.helperVr is not defined, did you mean helperVar", ".Env.GOMAKE_TEST_UNSET is not set"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := "{{includeFile \"" + helper + "\"}}\nshow:\n  script:\n    - echo " + tt.usage + "\n"
+			r := NewInterpreter("gomake", "", "sh", false, command.NewCommandHandler("gomake", 0), []byte(file))
+			r.MakeFile = "gomake.yml"
+			r.Strict = true
+
+			_, _, err := r.GetExecuteTemplate(file, make(map[string]any))
+			got := make([]string, 0)
+			if undefined, ok := err.(UndefinedVarsError); ok {
+				for _, e := range undefined {
+					got = append(got, e.Message)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if len(tt.want) == 0 && len(got) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package interpreter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// templateFuncError is an error of a gomake template function, it stops the rendering also without strict
+type templateFuncError struct {
+	error
+}
+
+func (e templateFuncError) Unwrap() error {
+	return e.error
+}
+
+func isTemplateFuncError(err error) bool {
+	var funcErr templateFuncError
+	return errors.As(err, &funcErr)
+}
+
+// runShell executes cmd with /bin/sh and returns its output, stderr is shown and part of the error
+func runShell(cmd string) (string, error) {
+	cmdRunner := exec.Command("/bin/sh", "-c", cmd)
+	buf := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmdRunner.Stdout = buf
+	cmdRunner.Stderr = io.MultiWriter(log.Writer(), stderr)
+
+	if err := cmdRunner.Run(); err != nil {
+		msg := fmt.Sprintf("shell %q failed", cmd)
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			msg += fmt.Sprintf(" with exit code %d", exitErr.ExitCode())
+		} else {
+			msg += fmt.Sprintf(": %s", err)
+		}
+		if out := strings.TrimSpace(stderr.String()); out != "" {
+			msg += fmt.Sprintf(": %s", out)
+		}
+		return "", templateFuncError{errors.New(msg)}
+	}
+	return buf.String(), nil
+}
+
+// runShellOr returns the output of cmd or fallback if cmd fails
+func runShellOr(cmd, fallback string) string {
+	cmdRunner := exec.Command("/bin/sh", "-c", cmd)
+	buf := new(bytes.Buffer)
+	cmdRunner.Stdout = buf
+	if err := cmdRunner.Run(); err != nil {
+		return fallback
+	}
+	return buf.String()
+}
+
+// includeFileOptions are the options of includeFile like {{includeFile "./local.yml" (dict "optional" true)}}
+type includeFileOptions struct {
+	// skip files which do not exist (also 404 of urls)
+	optional bool
+	// expected sha256 of the content of an url or a file of a git repository
+	sha256 string
+	// prefix of all included commands, given as string argument or as "as"
+	namespace string
+}
+
+func getIncludeFileOptions(options []any) (includeFileOptions, error) {
+	res := includeFileOptions{}
+	for _, option := range options {
+		switch o := option.(type) {
+		case string:
+			res.namespace = o
+		case map[string]any:
+			if optional, ok := o["optional"].(bool); ok {
+				res.optional = optional
+			}
+			if sum, ok := o["sha256"]; ok {
+				res.sha256 = fmt.Sprint(sum)
+			}
+			if namespace, ok := o["as"]; ok {
+				res.namespace = fmt.Sprint(namespace)
+			}
+		default:
+			return res, fmt.Errorf("invalid option %v, only a namespace or a dict are allowed", option)
+		}
+	}
+	if res.namespace != "" {
+		if err := validateNamespace(res.namespace); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// getIncludeFileContents returns the contents of path, optional files which do not exist are skipped
+func (r *Interpreter) getIncludeFileContents(path string, options includeFileOptions) ([]FileContent, error) {
+	var files []FileContent
+	var err error
+	if isGitPath(path) {
+		files, err = r.getGitContents(path, options.sha256)
+	} else if isRemotePath(path) {
+		var f FileContent
+		f, err = r.getRemoteContent(path, options.sha256)
+		files = []FileContent{f}
+	} else {
+		files, err = GetContents(path)
+	}
+	if err != nil {
+		if options.optional && errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, templateFuncError{errors.Wrapf(err, "includeFile %s", path)}
+	}
+	return files, nil
+}
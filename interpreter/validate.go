@@ -0,0 +1,194 @@
+package interpreter
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fasibio/gomake/command"
+	nearfinder "github.com/fasibio/gomake/nearFinder"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic is a problem found by Validate
+type Diagnostic struct {
+	File     string
+	Line     int
+	Severity string
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	if d.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s: %s", d.File, d.Line, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", d.File, d.Severity, d.Message)
+}
+
+type validator struct {
+	r           *Interpreter
+	diagnostics []Diagnostic
+}
+
+func (v *validator) add(file string, line int, severity, format string, args ...any) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{File: file, Line: line, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) addCommand(name, severity, format string, args ...any) {
+	file, line := v.r.findCommandLine(name)
+	v.add(file, line, severity, "%s: %s", name, fmt.Sprintf(format, args...))
+}
+
+// Validate checks the gomake file without executing it and returns all found problems
+func (r *Interpreter) Validate() []Diagnostic {
+	v := validator{r: r}
+	if _, err := GetConfig(r.MakeFile, r.commandFile); err != nil {
+		v.addTemplateError(err)
+	}
+
+	explizitMakeFile, variables, err := r.GetExecuteTemplate(string(r.commandFile), make(map[string]any))
+	if err != nil {
+		v.addTemplateError(err)
+		return v.diagnostics
+	}
+
+	var strictMakefile command.MakeStruct
+	if err := yaml.UnmarshalStrict(explizitMakeFile, &strictMakefile); err != nil {
+		v.addYamlError(err)
+	}
+	makefile, err := r.getMakeScripts(explizitMakeFile)
+	if err != nil {
+		return v.diagnostics
+	}
+
+	names := nearfinder.GetKeysOfMap(makefile)
+	sort.Strings(names)
+	for _, name := range names {
+		v.validateOperation(name, makefile)
+	}
+	for _, name := range names {
+		if _, err := r.cmdHandler.GetExecutedCommandMakeScript(name, makefile); err != nil {
+			v.addCommand(name, SeverityError, "%s", err)
+		}
+	}
+	v.validateStages(names, makefile, variables)
+	return v.diagnostics
+}
+
+func (v *validator) validateOperation(name string, makefile command.MakeStruct) {
+	operation := makefile[name]
+	if operation.Color != "" {
+		if _, ok := colorsMap[operation.Color]; !ok {
+			v.addCommand(name, SeverityError, "unknown color %s%s", operation.Color, didYouMean(operation.Color, nearfinder.GetKeysOfMap(colorsMap)))
+		}
+	}
+	if operation.Image != nil && operation.Image.Name == "" {
+		v.addCommand(name, SeverityError, "image.name is required")
+	}
+	if len(operation.Script) == 0 {
+		v.addCommand(name, SeverityWarning, "script is empty")
+	}
+	if _, err := v.r.getTimeout(operation); err != nil {
+		v.addCommand(name, SeverityError, "%s", err)
+	}
+	if _, err := getRetryPolicy(operation.Retry); err != nil {
+		v.addCommand(name, SeverityError, "%s", err)
+	}
+	if _, err := getNeedsOrder(name, makefile); err != nil {
+		v.addCommand(name, SeverityError, "%s", err)
+	}
+	for _, problem := range validateParams(operation.Params) {
+		v.addCommand(name, SeverityError, "%s", problem)
+	}
+}
+
+func (v *validator) validateStages(names []string, makefile command.MakeStruct, variables VarsDocument) {
+	stages := make(map[string][]string)
+	for _, name := range names {
+		if stage := makefile[name].Stage; stage != "" {
+			stages[stage] = append(stages[stage], name)
+		}
+	}
+	for stage, commands := range stages {
+		if len(commands) == 1 {
+			v.addCommand(commands[0], SeverityWarning, "stage %s is only used by this command", stage)
+		}
+	}
+	for _, stage := range variables.Stages {
+		if _, ok := stages[stage]; !ok {
+			v.add(v.r.MakeFile, 0, SeverityError, "stage %s is declared at stages but no command use it", stage)
+		}
+	}
+	for stage := range variables.StageConfig {
+		if _, ok := stages[stage]; !ok {
+			v.add(v.r.MakeFile, 0, SeverityWarning, "stage_config for unknown stage %s", stage)
+		}
+	}
+}
+
+func (v *validator) addYamlError(err error) {
+	messages := []string{err.Error()}
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		messages = typeErr.Errors
+	}
+	for _, msg := range messages {
+		if line, message, ok := parseYamlError(msg); ok {
+			file, line := v.r.sourceMap.locate(line)
+			v.add(file, line, SeverityError, "%s", message)
+			continue
+		}
+		v.add(v.r.MakeFile, 0, SeverityError, "%s", strings.TrimPrefix(msg, "yaml: "))
+	}
+}
+
+func (v *validator) addTemplateError(err error) {
+	var undefined UndefinedVarsError
+	if errors.As(err, &undefined) {
+		for _, e := range undefined {
+			v.add(e.File, e.Line, SeverityError, "%s", e.Message)
+		}
+		return
+	}
+	// errors of templates and yaml are already mapped to their file and line
+	var templateErr *TemplateError
+	if errors.As(err, &templateErr) {
+		v.add(templateErr.File, templateErr.Line, SeverityError, "%s", templateErr.Message)
+		return
+	}
+	v.add(v.r.MakeFile, 0, SeverityError, "%s", err)
+}
+
+func didYouMean(match string, checkList []string) string {
+	if closest := nearfinder.ClosestMatchOrTypo(match, checkList, 2); closest != "" {
+		return fmt.Sprintf(", did you mean %s", closest)
+	}
+	return ""
+}
+
+// findCommandLine returns the file and line where the command is defined (line is 0 if unknown)
+func (r *Interpreter) findCommandLine(name string) (string, int) {
+	file := r.getCommandSource(name)
+	content := r.commandFile
+	if file != r.MakeFile {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return file, 0
+		}
+		content = b
+	}
+	re := regexp.MustCompile(`^["']?` + regexp.QuoteMeta(r.getNameAtSource(name)) + `["']?\s*:`)
+	for i, line := range strings.Split(string(content), "\n") {
+		if re.MatchString(line) {
+			return file, i + 1
+		}
+	}
+	return file, 0
+}
@@ -0,0 +1,42 @@
+package interpreter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fasibio/gomake/command"
+)
+
+func TestValidateErrorLines(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want Diagnostic
+	}{
+		{
+			name: "yaml syntax",
+			file: "vars:\n  a: 1\n---\nb:\n  script: [\n",
+			want: Diagnostic{File: "gomake.yml", Line: 5, Severity: SeverityError, Message: "did not find expected node content"},
+		},
+		{
+			name: "unknown field",
+			file: "vars:\n  a: 1\n---\nb:\n  scrpit:\n    - echo\n",
+			want: Diagnostic{File: "gomake.yml", Line: 5, Severity: SeverityError, Message: "field scrpit not found in type command.Operation"},
+		},
+		{
+			name: "template function",
+			file: "vars:\n  a: 1\n---\nb:\n  script:\n    - echo {{ nofunc }}\n",
+			want: Diagnostic{File: "gomake.yml", Line: 6, Severity: SeverityError, Message: `function "nofunc" not defined`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewInterpreter("gomake", "", "sh", false, command.NewCommandHandler("gomake", 0), []byte(tt.file))
+			r.MakeFile = "gomake.yml"
+			diagnostics := r.Validate()
+			if len(diagnostics) == 0 || !reflect.DeepEqual(diagnostics[0], tt.want) {
+				t.Errorf("got %v, want %v", diagnostics, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package interpreter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SetExtraVariable sets the variable given by --var or --var-json, a dotted key (like docker.tag) sets a nested variable
+func (r *Interpreter) SetExtraVariable(key string, value any) {
+	setVar(r.ExtraVariables, key, value)
+}
+
+// SetExtraJsonVariable sets the variable given by --var-json key=json
+func (r *Interpreter) SetExtraJsonVariable(key, value string) error {
+	var v any
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		return fmt.Errorf("value of %s is no valid json: %s", key, err)
+	}
+	r.SetExtraVariable(key, v)
+	return nil
+}
+
+// AddExtraVariableFile merges all variables of the yaml (or json) file given by --var-file
+func (r *Interpreter) AddExtraVariableFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	vars := make(map[string]any)
+	if err := yaml.Unmarshal(b, &vars); err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	mergeVars(r.ExtraVariables, vars)
+	return nil
+}
+
+// mergeVars merges src into dst, nested maps are merged instead of replaced.
+// Maps of src are copied, so later merges into dst do not change src.
+func mergeVars(dst map[string]any, src map[string]any) {
+	for k, v := range src {
+		srcMap, ok := toVarsMap(v)
+		if !ok {
+			dst[k] = v
+			continue
+		}
+		dstMap, ok := toVarsMap(dst[k])
+		if !ok {
+			dstMap = make(map[string]any)
+		} else {
+			dstMap = copyVars(dstMap)
+		}
+		mergeVars(dstMap, srcMap)
+		dst[k] = dstMap
+	}
+}
+
+func copyVars(vars map[string]any) map[string]any {
+	res := make(map[string]any)
+	for k, v := range vars {
+		res[k] = v
+	}
+	return res
+}
+
+// toVarsMap returns v as map[string]any, yaml decodes nested maps as map[interface{}]interface{}
+func toVarsMap(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case map[any]any:
+		res := make(map[string]any)
+		for k, v := range m {
+			res[fmt.Sprintf("%v", k)] = v
+		}
+		return res, true
+	}
+	return nil, false
+}
+
+// setVar sets the var with a dotted key (like docker.tag), missing maps are created
+func setVar(vars map[string]any, key string, value any) {
+	path := strings.Split(key, ".")
+	for _, k := range path[:len(path)-1] {
+		next, ok := toVarsMap(vars[k])
+		if !ok {
+			next = make(map[string]any)
+		}
+		// toVarsMap returns a copy for yaml maps
+		vars[k] = next
+		vars = next
+	}
+	vars[path[len(path)-1]] = value
+}
+
+// lookupVar returns the var with a dotted key (like docker.tag)
+func lookupVar(vars map[string]any, key string) (any, bool) {
+	path := strings.Split(key, ".")
+	for _, k := range path[:len(path)-1] {
+		next, ok := toVarsMap(vars[k])
+		if !ok {
+			return nil, false
+		}
+		vars = next
+	}
+	v, ok := vars[path[len(path)-1]]
+	return v, ok
+}
@@ -2,15 +2,19 @@ package main
 
 import (
 	"embed"
+	"encoding/json"
 	"fmt"
 	"os"
 	"regexp"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/fasibio/gomake/command"
 	"github.com/fasibio/gomake/interpreter"
 	nearfinder "github.com/fasibio/gomake/nearFinder"
 	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
 )
 
 const (
@@ -19,7 +23,22 @@ const (
 	ExecuterCli            = "executer"
 	App                    = "GOMAKE"
 	DryRunCli              = "dry-run"
+	ForceCli               = "force"
+	TimeoutCli             = "timeout"
+	GracePeriodCli         = "grace-period"
+	MaxParallelCli         = "max-parallel"
+	FailFastCli            = "fail-fast"
+	ParallelCli            = "parallel"
+	FormatCli              = "format"
+	MaxIncludeDepthCli     = "max-include-depth"
+	EnvFileCli             = "env-file"
+	ExportVarsCli          = "export-vars"
+	StrictCli              = "strict"
+	CacheTTLCli            = "cache-ttl"
+	OfflineCli             = "offline"
 	VarsCli                = "var"
+	VarsJsonCli            = "var-json"
+	VarsFileCli            = "var-file"
 	ShellAutocompleteCli   = "shell"
 	PersistAutocompleteCli = "persist"
 )
@@ -28,6 +47,15 @@ const (
 	GomakeDefaultFile = "gomake.yml"
 )
 
+const (
+	ListFormatText  = "text"
+	ListFormatTable = "table"
+	ListFormatJson  = "json"
+	ListFormatYaml  = "yaml"
+)
+
+var listFormats = []string{ListFormatText, ListFormatTable, ListFormatJson, ListFormatYaml}
+
 func getFlagEnvByFlagName(flagName string) string {
 	return fmt.Sprintf("%s_%s", App, strings.ToUpper(flagName))
 }
@@ -35,7 +63,7 @@ func getFlagEnvByFlagName(flagName string) string {
 var VariableFlagRegex *regexp.Regexp
 
 func init() {
-	VariableFlagRegex = regexp.MustCompile(`^[0-9aA-zZ]*=[0-9,aA-zZ]*$`)
+	VariableFlagRegex = regexp.MustCompile(`(?s)^([0-9a-zA-Z_-]+(?:\.[0-9a-zA-Z_-]+)*)=(.*)$`)
 
 }
 
@@ -47,13 +75,15 @@ var gomakeIni []byte
 
 func main() {
 	runner := Runner{
-		cmdHandler: command.NewCommandHandler(App),
+		cmdHandler: command.NewCommandHandler(App, command.DefaultMaxIncludeDepth),
 	}
 
 	app1 := &cli.App{
 		Usage:                "A helm like makefile",
 		EnableBashCompletion: true,
-		CommandNotFound:      runner.CommandNotFound,
+		// values of --var may contain commas
+		DisableSliceFlagSeparator: true,
+		CommandNotFound:           runner.CommandNotFound,
 		Flags: []cli.Flag{
 			&cli.PathFlag{
 				Name:    MakeFileCli,
@@ -69,6 +99,49 @@ func main() {
 				Value:   "/bin/sh",
 				Usage:   "Shell to execute gomakefile config",
 			},
+			&cli.DurationFlag{
+				Name:    TimeoutCli,
+				EnvVars: []string{getFlagEnvByFlagName(TimeoutCli)},
+				Usage:   "Maximum duration of each command script (0 is unlimited), the timeout field of a command overwrites it",
+			},
+			&cli.DurationFlag{
+				Name:    GracePeriodCli,
+				EnvVars: []string{getFlagEnvByFlagName(GracePeriodCli)},
+				Value:   10 * time.Second,
+				Usage:   "Time running scripts get after SIGINT/SIGTERM was forwarded before they are killed",
+			},
+			&cli.IntFlag{
+				Name:    MaxIncludeDepthCli,
+				EnvVars: []string{getFlagEnvByFlagName(MaxIncludeDepthCli)},
+				Value:   command.DefaultMaxIncludeDepth,
+				Usage:   "Maximum depth of nested includes (0 is unlimited)",
+			},
+			&cli.StringSliceFlag{
+				Name:    EnvFileCli,
+				EnvVars: []string{getFlagEnvByFlagName(EnvFileCli)},
+				Usage:   "Env file (KEY=value lines) loaded into .Env and the environment of all scripts",
+			},
+			&cli.BoolFlag{
+				Name:    ExportVarsCli,
+				EnvVars: []string{getFlagEnvByFlagName(ExportVarsCli)},
+				Usage:   fmt.Sprintf("Export all vars as %s_VAR_* to the environment of all scripts", App),
+			},
+			&cli.BoolFlag{
+				Name:    StrictCli,
+				EnvVars: []string{getFlagEnvByFlagName(StrictCli)},
+				Usage:   "Fail on undefined variables and template errors instead of rendering <no value>",
+			},
+			&cli.DurationFlag{
+				Name:    CacheTTLCli,
+				EnvVars: []string{getFlagEnvByFlagName(CacheTTLCli)},
+				Value:   interpreter.DefaultCacheTTL,
+				Usage:   fmt.Sprintf("Maximum age of remote includeFile urls cached at %s before they are downloaded again", interpreter.StateDir),
+			},
+			&cli.BoolFlag{
+				Name:    OfflineCli,
+				EnvVars: []string{getFlagEnvByFlagName(OfflineCli)},
+				Usage:   "Use only cached remote includeFile urls",
+			},
 		},
 		Commands: []*cli.Command{
 			{
@@ -99,9 +172,18 @@ func main() {
 				Usage:  "List all commands described at gomake yaml file",
 				Action: runner.List,
 				Before: runner.Before,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    FormatCli,
+						Aliases: []string{"o"},
+						EnvVars: []string{getFlagEnvByFlagName(FormatCli)},
+						Value:   ListFormatText,
+						Usage:   fmt.Sprintf("Output format one of %s", strings.Join(listFormats, ", ")),
+					},
+				},
 			},
 			{
-				ArgsUsage:    "{executed command name}",
+				ArgsUsage:    "{executed command name} [--param value...] [{next command name} [--param value...]...]",
 				Name:         "run",
 				Usage:        fmt.Sprintf("Run commands from %s file", GomakeDefaultFile),
 				BashComplete: runner.RunBashComplete,
@@ -112,12 +194,49 @@ func main() {
 						Value:   false,
 						Usage:   fmt.Sprintf("Only show template paresed %s but not execute it", GomakeDefaultFile),
 					},
+					&cli.BoolFlag{
+						Name:    ForceCli,
+						EnvVars: []string{getFlagEnvByFlagName(ForceCli)},
+						Value:   false,
+						Usage:   "Execute commands even if their sources are up to date",
+					},
+					&cli.BoolFlag{
+						Name:    ParallelCli,
+						EnvVars: []string{getFlagEnvByFlagName(ParallelCli)},
+						Value:   false,
+						Usage:   "Run several given commands in parallel (like srun) instead of one after another",
+					},
+					&cli.IntFlag{
+						Name:    MaxParallelCli,
+						EnvVars: []string{getFlagEnvByFlagName(MaxParallelCli)},
+						Value:   0,
+						Usage:   "Maximum number of commands running at the same time with --parallel (0 is unlimited)",
+					},
+					&cli.BoolFlag{
+						Name:    FailFastCli,
+						EnvVars: []string{getFlagEnvByFlagName(FailFastCli)},
+						Value:   false,
+						Usage:   "Cancel all other commands as soon as one fails with --parallel",
+					},
+					&cli.StringSliceFlag{
+						Name:    VarsFileCli,
+						EnvVars: []string{getFlagEnvByFlagName(VarsFileCli)},
+						Usage:   "yaml (or json) file with variables merged into vars",
+						Action:  runner.ExtraVariableFiles,
+					},
 					&cli.StringSliceFlag{
 						Name:    VarsCli,
 						Aliases: []string{"v"},
 						EnvVars: []string{getFlagEnvByFlagName(VarsCli)},
+						Usage:   "Set a variable as key=value (docker.tag=value sets a nested variable)",
 						Action:  runner.ExtraVariables,
 					},
+					&cli.StringSliceFlag{
+						Name:    VarsJsonCli,
+						EnvVars: []string{getFlagEnvByFlagName(VarsJsonCli)},
+						Usage:   "Set a variable as key=json (like list='[\"a\",\"b\"]')",
+						Action:  runner.ExtraJsonVariables,
+					},
 				},
 				Action: runner.Run,
 				Before: runner.RunBefore,
@@ -134,21 +253,141 @@ func main() {
 						Value:   false,
 						Usage:   fmt.Sprintf("Only show template paresed %s but not execute it", GomakeDefaultFile),
 					},
+					&cli.BoolFlag{
+						Name:    ForceCli,
+						EnvVars: []string{getFlagEnvByFlagName(ForceCli)},
+						Value:   false,
+						Usage:   "Execute commands even if their sources are up to date",
+					},
+					&cli.StringSliceFlag{
+						Name:    VarsFileCli,
+						EnvVars: []string{getFlagEnvByFlagName(VarsFileCli)},
+						Usage:   "yaml (or json) file with variables merged into vars",
+						Action:  runner.ExtraVariableFiles,
+					},
 					&cli.StringSliceFlag{
 						Name:    VarsCli,
 						Aliases: []string{"v"},
 						EnvVars: []string{getFlagEnvByFlagName(VarsCli)},
+						Usage:   "Set a variable as key=value (docker.tag=value sets a nested variable)",
 						Action:  runner.ExtraVariables,
 					},
+					&cli.StringSliceFlag{
+						Name:    VarsJsonCli,
+						EnvVars: []string{getFlagEnvByFlagName(VarsJsonCli)},
+						Usage:   "Set a variable as key=json (like list='[\"a\",\"b\"]')",
+						Action:  runner.ExtraJsonVariables,
+					},
+					&cli.IntFlag{
+						Name:    MaxParallelCli,
+						EnvVars: []string{getFlagEnvByFlagName(MaxParallelCli)},
+						Usage:   "Maximum of commands running at the same time (0 is unlimited), overwrites max_parallel of stage_config",
+					},
+					&cli.BoolFlag{
+						Name:    FailFastCli,
+						EnvVars: []string{getFlagEnvByFlagName(FailFastCli)},
+						Usage:   "Cancel all other commands of the stage as soon as one fails",
+					},
 				},
 				Action: runner.SRun,
 				Before: runner.RunBefore,
 			},
+			{
+				Name:   "validate",
+				Usage:  "Check the gomake file (template, yaml, includes, needs, colors, stages) without executing it",
+				Action: runner.Validate,
+				Before: runner.Before,
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:    VarsFileCli,
+						EnvVars: []string{getFlagEnvByFlagName(VarsFileCli)},
+						Usage:   "yaml (or json) file with variables merged into vars",
+						Action:  runner.ExtraVariableFiles,
+					},
+					&cli.StringSliceFlag{
+						Name:    VarsCli,
+						Aliases: []string{"v"},
+						EnvVars: []string{getFlagEnvByFlagName(VarsCli)},
+						Usage:   "Set a variable as key=value (docker.tag=value sets a nested variable)",
+						Action:  runner.ExtraVariables,
+					},
+					&cli.StringSliceFlag{
+						Name:    VarsJsonCli,
+						EnvVars: []string{getFlagEnvByFlagName(VarsJsonCli)},
+						Usage:   "Set a variable as key=json (like list='[\"a\",\"b\"]')",
+						Action:  runner.ExtraJsonVariables,
+					},
+				},
+			},
+			{
+				Name:  "pipeline",
+				Usage: "Run all stages declared at stages of the vars document one after another (each stage like srun)",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    DryRunCli,
+						EnvVars: []string{getFlagEnvByFlagName(DryRunCli)},
+						Value:   false,
+						Usage:   fmt.Sprintf("Only show template paresed %s but not execute it", GomakeDefaultFile),
+					},
+					&cli.BoolFlag{
+						Name:    ForceCli,
+						EnvVars: []string{getFlagEnvByFlagName(ForceCli)},
+						Value:   false,
+						Usage:   "Execute commands even if their sources are up to date",
+					},
+					&cli.StringSliceFlag{
+						Name:    VarsFileCli,
+						EnvVars: []string{getFlagEnvByFlagName(VarsFileCli)},
+						Usage:   "yaml (or json) file with variables merged into vars",
+						Action:  runner.ExtraVariableFiles,
+					},
+					&cli.StringSliceFlag{
+						Name:    VarsCli,
+						Aliases: []string{"v"},
+						EnvVars: []string{getFlagEnvByFlagName(VarsCli)},
+						Usage:   "Set a variable as key=value (docker.tag=value sets a nested variable)",
+						Action:  runner.ExtraVariables,
+					},
+					&cli.StringSliceFlag{
+						Name:    VarsJsonCli,
+						EnvVars: []string{getFlagEnvByFlagName(VarsJsonCli)},
+						Usage:   "Set a variable as key=json (like list='[\"a\",\"b\"]')",
+						Action:  runner.ExtraJsonVariables,
+					},
+					&cli.IntFlag{
+						Name:    MaxParallelCli,
+						EnvVars: []string{getFlagEnvByFlagName(MaxParallelCli)},
+						Usage:   "Maximum of commands running at the same time (0 is unlimited), overwrites max_parallel of stage_config",
+					},
+					&cli.BoolFlag{
+						Name:    FailFastCli,
+						EnvVars: []string{getFlagEnvByFlagName(FailFastCli)},
+						Usage:   "Cancel all other commands of the stage as soon as one fails",
+					},
+				},
+				Action: runner.Pipeline,
+				Before: runner.PipelineBefore,
+			},
+			{
+				Name:  "deps",
+				Usage: "Manage the remote includes pinned at gomake.lock",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "update",
+						Usage:     "Resolve the refs of git includes to their latest commit and download urls again",
+						ArgsUsage: "[repository or url...]",
+						Action:    runner.DepsUpdate,
+						Before:    runner.Before,
+					},
+				},
+			},
 		},
 	}
 
 	if err := app1.Run(os.Args); err != nil {
 		fmt.Println("Error: ", err)
+		// only errors implementing cli.ExitCoder (like a failed script) exit by cli
+		os.Exit(1)
 	}
 }
 
@@ -159,27 +398,112 @@ type Runner struct {
 
 func (r *Runner) ExtraVariables(ctx *cli.Context, s []string) error {
 	for _, a := range s {
-		if ok := VariableFlagRegex.MatchString(a); !ok {
+		splittetVar := VariableFlagRegex.FindStringSubmatch(a)
+		if splittetVar == nil {
 			return fmt.Errorf("Variable does not match: %s only \"key=value\" are allowed ", a)
 		}
-		splittetVar := strings.SplitN(a, "=", 2)
-		r.interpreter.ExtraVariables[splittetVar[0]] = splittetVar[1]
+		r.interpreter.SetExtraVariable(splittetVar[1], splittetVar[2])
 	}
 	return nil
 }
 
+func (r *Runner) ExtraJsonVariables(ctx *cli.Context, s []string) error {
+	for _, a := range s {
+		splittetVar := VariableFlagRegex.FindStringSubmatch(a)
+		if splittetVar == nil {
+			return fmt.Errorf("Variable does not match: %s only \"key=json\" are allowed ", a)
+		}
+		if err := r.interpreter.SetExtraJsonVariable(splittetVar[1], splittetVar[2]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Runner) ExtraVariableFiles(ctx *cli.Context, s []string) error {
+	for _, f := range s {
+		if err := r.interpreter.AddExtraVariableFile(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyConfig sets the flags of the command which are not given at the command line to the values of the config document
+func applyConfig(c *cli.Context, config interpreter.Config) error {
+	values := map[string]string{
+		ExecuterCli:        config.Shell,
+		TimeoutCli:         config.Timeout,
+		GracePeriodCli:     config.GracePeriod,
+		MaxIncludeDepthCli: config.MaxIncludeDepth,
+		CacheTTLCli:        config.CacheTTL,
+		StrictCli:          config.Strict,
+		ExportVarsCli:      config.ExportVars,
+		MaxParallelCli:     config.MaxParallel,
+		FailFastCli:        config.FailFast,
+	}
+	for name, value := range values {
+		if value == "" || c.IsSet(name) || !hasFlag(c, name) {
+			continue
+		}
+		if err := c.Set(name, value); err != nil {
+			return fmt.Errorf("invalid value %q of %s at config: %s", value, name, err)
+		}
+	}
+	return nil
+}
+
+func hasFlag(c *cli.Context, name string) bool {
+	for _, ctx := range c.Lineage() {
+		var flags []cli.Flag
+		if ctx.Command != nil {
+			flags = ctx.Command.Flags
+		} else if ctx.App != nil {
+			flags = ctx.App.Flags
+		}
+		for _, flag := range flags {
+			for _, n := range flag.Names() {
+				if n == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 func (r *Runner) RunBefore(c *cli.Context) error {
-	r.Before(c)
+	if err := r.Before(c); err != nil {
+		return err
+	}
 	neededCommand := c.Args().Get(0)
 	dryRun := c.Bool(DryRunCli)
 	if neededCommand == "" {
 		return fmt.Errorf("need name of executing command")
 	}
 	r.interpreter.ExecuteCommand = neededCommand
+	r.interpreter.ParamArgs = c.Args().Tail()
 	r.interpreter.DryRun = dryRun
+	r.setExecutionFlags(c)
 	return nil
 }
 
+func (r *Runner) PipelineBefore(c *cli.Context) error {
+	if err := r.Before(c); err != nil {
+		return err
+	}
+	r.interpreter.DryRun = c.Bool(DryRunCli)
+	r.setExecutionFlags(c)
+	return nil
+}
+
+func (r *Runner) setExecutionFlags(c *cli.Context) {
+	r.interpreter.Force = c.Bool(ForceCli)
+	r.interpreter.MaxParallel = c.Int(MaxParallelCli)
+	r.interpreter.FailFast = c.Bool(FailFastCli)
+	r.interpreter.Parallel = c.Bool(ParallelCli)
+}
+
 func (r *Runner) CommandNotFound(c *cli.Context, cmd string) {
 	possibleCommands := []string{}
 	for _, v := range c.App.Commands {
@@ -190,12 +514,28 @@ func (r *Runner) CommandNotFound(c *cli.Context, cmd string) {
 
 func (r *Runner) Before(c *cli.Context) error {
 	makefile := c.Path(MakeFileCli)
-	executer := c.String(ExecuterCli)
 	f, err := os.ReadFile(makefile)
 	if err != nil {
 		return err
 	}
+	config, err := interpreter.GetConfig(makefile, f)
+	if err != nil {
+		return err
+	}
+	if err := applyConfig(c, config); err != nil {
+		return err
+	}
+	executer := c.String(ExecuterCli)
+	r.cmdHandler = command.NewCommandHandler(App, c.Int(MaxIncludeDepthCli))
 	r.interpreter = interpreter.NewInterpreter(App, "", executer, c.Bool(DryRunCli), r.cmdHandler, f)
+	r.interpreter.MakeFile = makefile
+	r.interpreter.Timeout = c.Duration(TimeoutCli)
+	r.interpreter.GracePeriod = c.Duration(GracePeriodCli)
+	r.interpreter.EnvFiles = c.StringSlice(EnvFileCli)
+	r.interpreter.ExportVars = c.Bool(ExportVarsCli)
+	r.interpreter.Strict = c.Bool(StrictCli)
+	r.interpreter.CacheTTL = c.Duration(CacheTTLCli)
+	r.interpreter.Offline = c.Bool(OfflineCli)
 	return nil
 }
 
@@ -277,8 +617,26 @@ func (r *Runner) RunBashComplete(c *cli.Context) {
 	if err != nil {
 		return
 	}
-	for k := range list {
-		autoCompleteHelp = append(autoCompleteHelp, k)
+	// params of the last given command
+	args := c.Args().Slice()
+	last := -1
+	for i, a := range args {
+		if _, ok := list[a]; ok {
+			last = i
+		}
+	}
+	if last >= 0 {
+		params, enum := getParamsAutoComplete(list[args[last]], args[last+1:])
+		if enum {
+			autoCompleteHelp = params
+		} else {
+			autoCompleteHelp = append(autoCompleteHelp, params...)
+		}
+	}
+	if last < 0 || len(args) == last+1 || !strings.HasPrefix(args[len(args)-1], "-") {
+		for k := range list {
+			autoCompleteHelp = append(autoCompleteHelp, k)
+		}
 	}
 
 	for _, v := range autoCompleteHelp {
@@ -286,6 +644,25 @@ func (r *Runner) RunBashComplete(c *cli.Context) {
 	}
 }
 
+// getParamsAutoComplete returns the enum values (and true) if the last arg is a param with enum, otherwise all not used params
+func getParamsAutoComplete(operation command.Operation, args []string) ([]string, bool) {
+	res := make([]string, 0)
+	if len(args) > 0 {
+		last := strings.TrimLeft(args[len(args)-1], "-")
+		for _, p := range operation.Params {
+			if p.Name == last && len(p.Enum) > 0 {
+				return p.Enum, true
+			}
+		}
+	}
+	for _, p := range operation.Params {
+		if !isFlagAtUseList(args, "--"+p.Name) {
+			res = append(res, "--"+p.Name)
+		}
+	}
+	return res, false
+}
+
 func (r *Runner) Autocomplete(c *cli.Context) error {
 	persist := c.Bool(PersistAutocompleteCli)
 	shell := c.String(ShellAutocompleteCli)
@@ -336,13 +713,49 @@ func (r *Runner) Autocomplete(c *cli.Context) error {
 }
 
 func (r *Runner) Run(c *cli.Context) error {
+	r.interpreter.Prompt = true
 	return r.interpreter.Run()
 }
 
 func (r *Runner) SRun(c *cli.Context) error {
+	r.interpreter.Prompt = true
 	return r.interpreter.SRun()
 }
 
+func (r *Runner) Pipeline(c *cli.Context) error {
+	r.interpreter.Prompt = true
+	return r.interpreter.Pipeline()
+}
+
+func (r *Runner) DepsUpdate(c *cli.Context) error {
+	changes, err := r.interpreter.UpdateDeps(c.Args().Slice())
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Println("All dependencies are up to date")
+	}
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+	return nil
+}
+
+func (r *Runner) Validate(c *cli.Context) error {
+	errCount := 0
+	for _, d := range r.interpreter.Validate() {
+		fmt.Println(d)
+		if d.Severity == interpreter.SeverityError {
+			errCount++
+		}
+	}
+	if errCount > 0 {
+		return cli.Exit(fmt.Sprintf("%d error(s) found", errCount), 1)
+	}
+	fmt.Printf("%s is valid\n", c.Path(MakeFileCli))
+	return nil
+}
+
 func (r *Runner) Init(c *cli.Context) error {
 	_, err := os.Stat(GomakeDefaultFile)
 	if err != nil {
@@ -363,22 +776,54 @@ func (r *Runner) Init(c *cli.Context) error {
 }
 
 func (r *Runner) List(c *cli.Context) error {
-	list, err := r.interpreter.GetMakeScripts()
+	list, err := r.interpreter.GetCommandList()
 	if err != nil {
 		return err
 	}
-	fmt.Println("List of executed Commands (for run):")
-	for k := range list {
-		fmt.Printf("%s  %s \n", k, list[k].Doc)
-	}
+	switch c.String(FormatCli) {
+	case ListFormatText:
+		fmt.Println("List of executed Commands (for run):")
+		namespace := ""
+		for _, v := range list.Commands {
+			if v.Namespace != namespace {
+				namespace = v.Namespace
+				fmt.Printf("\n%s%s\n", namespace, interpreter.NamespaceSeparator)
+			}
+			indent := ""
+			if namespace != "" {
+				indent = "  "
+			}
+			fmt.Printf("%s%s  %s \n", indent, v.Name, v.Doc)
+			for _, p := range v.Params {
+				fmt.Printf("%s    %s\n", indent, p.Usage)
+			}
+		}
 
-	fmt.Println("\nList of executed Stages (for srun):")
-	stages, _, _, err := r.interpreter.GetStageMap()
-	if err != nil {
-		return err
-	}
-	for k := range stages {
-		fmt.Println(k)
+		fmt.Println("\nList of executed Stages (for srun):")
+		for _, v := range list.Stages {
+			fmt.Println(v.Name)
+		}
+	case ListFormatTable:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAMESPACE\tNAME\tSTAGE\tIMAGE\tSOURCE\tDOC")
+		for _, v := range list.Commands {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", v.Namespace, v.Name, v.Stage, v.Image, v.Source, v.Doc)
+		}
+		return w.Flush()
+	case ListFormatJson:
+		b, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+	case ListFormatYaml:
+		b, err := yaml.Marshal(list)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(b))
+	default:
+		return fmt.Errorf("unknown format %s only %s are allowed", c.String(FormatCli), strings.Join(listFormats, ", "))
 	}
 	return nil
 }
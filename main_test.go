@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testArgsEnv = "GOMAKE_TEST_ARGS"
+
+// TestMain runs main with the args of testArgsEnv instead of the tests, so runGomake can check the exit status
+func TestMain(m *testing.M) {
+	if args := os.Getenv(testArgsEnv); args != "" {
+		os.Args = append([]string{"gomake"}, strings.Split(args, "\n")...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runGomake runs gomake with gomakeFile as gomake.yml and returns the output and the exit status
+func runGomake(t *testing.T, gomakeFile string, args ...string) (string, int) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, GomakeDefaultFile), []byte(gomakeFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(os.Args[0])
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), testArgsEnv+"="+strings.Join(args, "\n"))
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(out), exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), 0
+}
+
+func TestExitStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		gomakeFile string
+		args       []string
+		want       int
+	}{
+		{
+			name:       "pipeline with failed stage",
+			gomakeFile: "stages: [build]\n---\nfail:\n  stage: build\n  script:\n    - exit 3\n",
+			args:       []string{"pipeline"},
+			want:       1,
+		},
+		{
+			name:       "pipeline",
+			gomakeFile: "stages: [build]\n---\nok:\n  stage: build\n  script:\n    - echo ok\n",
+			args:       []string{"pipeline"},
+			want:       0,
+		},
+		{
+			name:       "run with failed command",
+			gomakeFile: "fail:\n  script:\n    - exit 3\nok:\n  script:\n    - echo ok\n",
+			args:       []string{"run", "ok", "fail"},
+			want:       1,
+		},
+		{
+			name:       "run parallel with failed command",
+			gomakeFile: "fail:\n  script:\n    - exit 3\nok:\n  script:\n    - echo ok\n",
+			args:       []string{"run", "--parallel", "ok", "fail"},
+			want:       1,
+		},
+		{
+			name:       "needs cycle",
+			gomakeFile: "a:\n  needs: [b]\n  script:\n    - echo a\nb:\n  needs: [a]\n  script:\n    - echo b\n",
+			args:       []string{"run", "a"},
+			want:       1,
+		},
+		{
+			name:       "failed need",
+			gomakeFile: "a:\n  needs: [b]\n  script:\n    - echo a\nb:\n  script:\n    - exit 3\n",
+			args:       []string{"run", "a"},
+			want:       1,
+		},
+		{
+			name:       "timed out command",
+			gomakeFile: "slow:\n  timeout: 100ms\n  script:\n    - sleep 5\n",
+			args:       []string{"run", "slow"},
+			want:       1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, status := runGomake(t, tt.gomakeFile, tt.args...)
+			if status != tt.want {
+				t.Errorf("got exit status %d, want %d\n%s", status, tt.want, out)
+			}
+		})
+	}
+}
+
+func TestRunTargetWhichIsNeed(t *testing.T) {
+	gomakeFile := "a:\n  needs: [b]\n  script:\n    - echo run-a\nb:\n  script:\n    - echo run-b\n"
+	for _, args := range [][]string{{"run", "a", "b"}, {"run", "--parallel", "a", "b"}, {"run", "b", "a"}} {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			out, status := runGomake(t, gomakeFile, args...)
+			if status != 0 {
+				t.Fatalf("got exit status %d\n%s", status, out)
+			}
+			count := 0
+			for _, line := range strings.Split(out, "\n") {
+				if strings.HasSuffix(line, "run-b") && !strings.Contains(line, "echo") {
+					count++
+				}
+			}
+			if count != 1 {
+				t.Errorf("b ran %d times, want once\n%s", count, out)
+			}
+		})
+	}
+}
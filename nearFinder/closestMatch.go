@@ -18,3 +18,56 @@ func ClosestMatch(match string, checkList []string, subsetSize int) string {
 	cm := closestmatch.New(checkList, bagSizes)
 	return cm.Closest(match)
 }
+
+// ClosestMatchOrTypo is like ClosestMatch, but if it finds nothing it returns the entry of checkList with the fewest typos
+// (inserted, removed, replaced or swapped characters, at most a third of match)
+func ClosestMatchOrTypo(match string, checkList []string, subsetSize int) string {
+	if closest := ClosestMatch(match, checkList, subsetSize); closest != "" {
+		return closest
+	}
+	maxTypos := len(match) / 3
+	if maxTypos < 1 {
+		maxTypos = 1
+	}
+	res := ""
+	for _, c := range checkList {
+		if d := typoDistance(match, c); d <= maxTypos {
+			maxTypos = d - 1
+			res = c
+		}
+	}
+	return res
+}
+
+// typoDistance is the optimal string alignment distance of a and b
+func typoDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(d[i-1][j]+1, minInt(d[i][j-1]+1, d[i-1][j-1]+cost))
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}